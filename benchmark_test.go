@@ -1,6 +1,8 @@
 package fit
 
 import (
+	"net/http"
+	"net/http/httptest"
 	"testing"
 )
 
@@ -12,6 +14,8 @@ type benchmarkRoute struct {
 
 var (
 	benchmarkRouter = NewRouter()
+	serveRouter     = NewRouter()
+	serveBody       = []byte("ok")
 	// https://developers.google.com/+/web/api/rest/latest/
 	googlePlusAPI = []benchmarkRoute{
 		{"GET", "/people/:userId", "/people/23"},
@@ -41,6 +45,42 @@ func insertRoutesForTesting() {
 	for _, route := range googlePlusAPI {
 		benchmarkRouter.addRoute(route.path, []string{route.method}, nil)
 	}
+
+	benchmarkRouter.addRoute("/constrained/:id", []string{"GET"}, nil).Where("id", "^[0-9]+$")
+
+	for _, route := range googlePlusAPI {
+		serveRouter.addRoute(route.path, []string{route.method}, func(c *Context) {
+			c.Writer().Write(serveBody)
+		})
+	}
+	serveRouter.Before(func(c *Context) {
+		c.Next()
+	})
+}
+
+// benchmarkWriter is a http.ResponseWriter without allocations, unlike httptest.ResponseRecorder
+type benchmarkWriter struct {
+	header http.Header
+}
+
+func (w *benchmarkWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *benchmarkWriter) Write(b []byte) (int, error) {
+	return len(b), nil
+}
+
+func (w *benchmarkWriter) WriteHeader(int) {}
+
+func benchmarkServe(path, method string, b *testing.B) {
+	w, req := &benchmarkWriter{http.Header{}}, httptest.NewRequest(method, path, nil)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		serveRouter.ServeHTTP(w, req)
+	}
 }
 
 func benchmarkFind(path, method string, b *testing.B) {
@@ -69,6 +109,11 @@ func BenchmarkFindCatchAllRoute(b *testing.B) {
 	benchmarkFind("/custom/some-custom-string", "GET", b)
 }
 
+func BenchmarkFindConstrainedRoute(b *testing.B) {
+	b.ReportAllocs()
+	benchmarkFind("/constrained/2346", "GET", b)
+}
+
 func BenchmarkFindAllRoutes(b *testing.B) {
 	for n := 0; n < b.N; n++ {
 		for _, route := range googlePlusAPI {
@@ -76,3 +121,15 @@ func BenchmarkFindAllRoutes(b *testing.B) {
 		}
 	}
 }
+
+func BenchmarkServeStaticRoute(b *testing.B) {
+	benchmarkServe("/people", "GET", b)
+}
+
+func BenchmarkServe1ParameterRoute(b *testing.B) {
+	benchmarkServe("/activities/44", "GET", b)
+}
+
+func BenchmarkServe5ParameterRoute(b *testing.B) {
+	benchmarkServe("/activities/22/comments/1/user/45645/favorites/242/latest/435", "GET", b)
+}
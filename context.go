@@ -1,9 +1,10 @@
 package fit
 
 import (
+	"context"
 	"encoding/json"
-	"fmt"
 	"net/http"
+	"time"
 )
 
 // Context gets supplied to all functions conforming to the ResponseHandler, when the route get's inserted.
@@ -32,6 +33,18 @@ type Context struct {
 
 	// Shared for handling shared values between middleware(s)
 	shared Shared
+
+	// Router handling the request
+	router *Router
+
+	// Index of the first after handler of the router in the chain
+	afterIndex int
+
+	// Whether the chain has been aborted using Abort
+	aborted bool
+
+	// Tracking writer wrapping the http.ResponseWriter, reused with the Context
+	response responseWriter
 }
 
 // ResponseHandler type, which every insertion of a route has to conform to, to receive the Context object
@@ -41,19 +54,61 @@ func newContext() *Context {
 	return &Context{}
 }
 
+// reset prepares the Context for a new request, keeping the allocated parameter stack
+func (c *Context) reset(w http.ResponseWriter, rq *http.Request, r *Router) {
+	*c = Context{
+		params:   Parameters{c.params.stack[:0]},
+		request:  rq,
+		router:   r,
+		response: responseWriter{ResponseWriter: w, c: c},
+	}
+	c.writer = &c.response
+}
+
 // Next calls the next middleware in the chain and returns a boolean value.
-// The boolean value is determined by whether the new middleware was found or not.
+// The boolean value is determined by whether the new middleware was called or not.
+// When the chain has been aborted, or the context of the request is done, only the after handlers
+// of the router are called, which are otherwise called by the router regardless
 func (c *Context) Next() bool {
-	if c.currentHandler < c.maxHandlers-1 {
-		c.currentHandler++
-		c.callByIndex(c.currentHandler)
+	next := c.currentHandler + 1
+	if next >= c.maxHandlers {
+		return false
+	}
 
-		return true
+	if next < c.afterIndex && (c.aborted || c.Err() != nil) {
+		return false
 	}
 
-	fmt.Println("There is no middleware left in the chain. Calling .Next() has no effect.")
+	c.currentHandler = next
+	c.callByIndex(next)
 
-	return false
+	return true
+}
+
+// Abort prevents the remaining handlers in the chain from being called, except the after handlers of the router.
+// It does not stop the current handler
+func (c *Context) Abort() {
+	c.aborted = true
+}
+
+// AbortWithStatus aborts the chain and writes the status code.
+func (c *Context) AbortWithStatus(code int) {
+	c.Abort()
+
+	if c.writable() {
+		c.setStatus(code)
+	}
+}
+
+// AbortWithJSON aborts the chain and writes the data as JSON, with the supplied status code.
+func (c *Context) AbortWithJSON(data interface{}, status ...int) {
+	c.Abort()
+	c.JSON(data, status...)
+}
+
+// IsAborted returns true if the chain has been aborted.
+func (c *Context) IsAborted() bool {
+	return c.aborted
 }
 
 // Writer returns an instance of the http.ResponseWriter.
@@ -66,11 +121,99 @@ func (c *Context) Request() *http.Request {
 	return c.request
 }
 
+// Copy returns a copy of the Context, safe to use after the request has finished, e.g. in a goroutine.
+// The Context itself is pooled and reused for other requests once the handlers have returned.
+// The copy holds the request, the parameters and a snapshot of the shared values, but can't write the response
+func (c *Context) Copy() *Context {
+	cp := &Context{
+		request: c.request,
+		status:  c.status,
+		params:  Parameters{append([]parameter(nil), c.params.stack...)},
+		router:  c.router,
+		aborted: c.aborted,
+	}
+	cp.response = responseWriter{ResponseWriter: &detachedWriter{make(http.Header)}, c: cp, wroteHeader: true, sealed: true}
+	cp.writer = &cp.response
+
+	if c.shared.values != nil {
+		cp.shared = Shared{make(map[string]interface{}, len(c.shared.values))}
+		for key, value := range c.shared.values {
+			cp.shared.values[key] = value
+		}
+	}
+
+	if c.request != nil && cp.shared.values != nil {
+		ctx := c.request.Context()
+		if shared, ok := ctx.(sharedContext); ok {
+			ctx = shared.Context
+		}
+		cp.request = c.request.WithContext(sharedContext{ctx, cp.shared.values})
+	}
+
+	return cp
+}
+
+// Deadline returns the deadline of the request context, making the Context satisfy context.Context.
+func (c *Context) Deadline() (deadline time.Time, ok bool) {
+	return c.requestContext().Deadline()
+}
+
+// Done returns a channel closed when the request is cancelled, the client disconnects or the timeout is exceeded.
+func (c *Context) Done() <-chan struct{} {
+	return c.requestContext().Done()
+}
+
+// Err returns the reason the request context is done, or nil if it's not.
+func (c *Context) Err() error {
+	return c.requestContext().Err()
+}
+
+// Value returns the value associated with the key in the request context.
+func (c *Context) Value(key interface{}) interface{} {
+	return c.requestContext().Value(key)
+}
+
+// requestContext returns the context of the request, or the background context if there's no request
+func (c *Context) requestContext() context.Context {
+	if c.request == nil {
+		return context.Background()
+	}
+
+	return c.request.Context()
+}
+
 // callByIndex calls a handler by given index.
+// When reaching the after handlers of the router with the response written, it's sealed,
+// so they can inspect but not clobber it
 func (c *Context) callByIndex(index int) {
+	if index == c.afterIndex && c.Written() {
+		c.writer.(*responseWriter).sealed = true
+	}
+
 	c.handlers[index](c)
 }
 
+// Written returns true when the response has been committed, i.e. the header has been written.
+// Once written, the response helpers (JSON, String, ...) are handled according to Router.WritePolicy
+func (c *Context) Written() bool {
+	w, ok := c.writer.(*responseWriter)
+	return ok && w.wroteHeader
+}
+
+// writable returns true when a response helper is allowed to write.
+// When the response has been written, the WritePolicy of the router decides whether to discard or panic
+func (c *Context) writable() bool {
+	if !c.Written() {
+		return true
+	}
+
+	if c.router != nil && c.router.WritePolicy == PanicOnWrite {
+		panic(ErrResponseWritten)
+	}
+
+	return false
+}
+
 // setStatus sets the status of the header.
 // It accepts multiple ints, to make it optional.
 // The default status set, will be the http.StatusOk => 200
@@ -87,19 +230,64 @@ func (c *Context) Status() int {
 	return c.status
 }
 
-// JSON tries to encode the given interface as JSON using json.Marshal() and write the result to body, with the supplied status code
+// Size returns the amount of bytes written to the body.
+func (c *Context) Size() int {
+	if w, ok := c.writer.(*responseWriter); ok {
+		return w.size
+	}
+
+	return 0
+}
+
+// JSON encodes the given interface as JSON using a json.Encoder, streaming the result to body with the supplied status code
 // Status code is optional and will use the default code set by the setStatus() function http.StatusOK => 200
-// If it fails, it will set the status as http.StatusInternalServerError and output the error
+// Indentation and HTML escaping are configured using Router.JSONIndent and Router.JSONEscapeHTML
+// If encoding fails, nothing is written and the error is passed to the error handler of the router
+// If the response has already been written, it's handled according to Router.WritePolicy
 func (c *Context) JSON(data interface{}, status ...int) {
-	b, err := json.Marshal(data)
+	if !c.writable() {
+		return
+	}
+
+	indent, escapeHTML := "", true
+	if c.router != nil {
+		indent, escapeHTML = c.router.JSONIndent, c.router.JSONEscapeHTML
+	}
 
-	if err != nil {
-		c.setStatus(http.StatusInternalServerError)
-		c.writer.Write([]byte(err.Error()))
+	encoder := json.NewEncoder(&deferredWriter{c: c, contentType: contentTypeJSON, status: status})
+	encoder.SetIndent("", indent)
+	encoder.SetEscapeHTML(escapeHTML)
 
+	if err := encoder.Encode(data); err != nil {
+		c.Error(err)
+	}
+}
+
+// Error passes the error to the error handler of the router, or the default one, rendering it as the response
+func (c *Context) Error(err error) {
+	if c.router != nil && c.router.ErrorHandler != nil {
+		c.router.ErrorHandler(c, err)
 		return
 	}
 
-	c.setStatus(status...)
-	c.writer.Write(b)
+	errorHandler()(c, err)
+}
+
+// deferredWriter sets the content type and status on the first write.
+// The json.Encoder only writes when the encoding succeeded, so nothing is written on failure
+type deferredWriter struct {
+	c           *Context
+	contentType string
+	status      []int
+	started     bool
+}
+
+func (w *deferredWriter) Write(b []byte) (int, error) {
+	if !w.started {
+		w.started = true
+		w.c.writer.Header().Set("Content-Type", w.contentType)
+		w.c.setStatus(w.status...)
+	}
+
+	return w.c.writer.Write(b)
 }
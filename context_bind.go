@@ -0,0 +1,257 @@
+package fit
+
+import (
+	"encoding"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// The struct tags used by Bind, in the order they are applied
+var bindingTags = []string{"form", "query", "header", "param"}
+
+// Maximum memory used when parsing multipart forms, the rest is stored in temporary files
+const multipartMemory = 32 << 20
+
+// ErrUnsupportedMediaType is returned by Bind when the body has a content type which can't be decoded.
+// The default error handler answers with http.StatusUnsupportedMediaType
+var ErrUnsupportedMediaType = errors.New("unsupported media type")
+
+// BindingError is returned by Bind, when a value can't be bound to the destination
+type BindingError struct {
+	// Field of the struct, empty when decoding the body failed
+	Field string
+
+	// Source of the value: "body", "form", "query", "header" or "param"
+	Source string
+
+	// Key of the value in the source
+	Key string
+
+	// Value which couldn't be bound
+	Value string
+
+	Err error
+}
+
+func (e *BindingError) Error() string {
+	if e.Field == "" {
+		return fmt.Sprintf("binding %s failed: %s", e.Source, e.Err)
+	}
+
+	return fmt.Sprintf("binding %s '%s' with value '%s' to field '%s' failed: %s", e.Source, e.Key, e.Value, e.Field, e.Err)
+}
+
+func (e *BindingError) Unwrap() error {
+	return e.Err
+}
+
+// Bind populates the struct pointed to by dst from the request.
+// The body is decoded according to the Content-Type, supporting JSON, XML and forms.
+// Afterwards the fields tagged with `form:"name"`, `query:"name"`, `header:"name"` and `param:"name"`
+// are populated from the form, query string, headers and path parameters respectively
+func (c *Context) Bind(dst interface{}) error {
+	value := reflect.ValueOf(dst)
+	if value.Kind() != reflect.Ptr || value.IsNil() || value.Elem().Kind() != reflect.Struct {
+		return errors.New("fit: bind destination must be a non-nil pointer to a struct")
+	}
+
+	if err := c.bindBody(dst); err != nil {
+		return err
+	}
+
+	return c.bindFields(value.Elem())
+}
+
+// bindBody decodes the body into dst according to the content type
+func (c *Context) bindBody(dst interface{}) error {
+	request := c.request
+	if request.Body == nil || request.Body == http.NoBody || request.ContentLength == 0 {
+		return nil
+	}
+
+	contentType := request.Header.Get("Content-Type")
+	if contentType == "" {
+		return nil
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return &BindingError{Source: "body", Err: err}
+	}
+
+	switch mediaType {
+	case "application/json":
+		err = json.NewDecoder(request.Body).Decode(dst)
+	case "application/xml", "text/xml":
+		err = xml.NewDecoder(request.Body).Decode(dst)
+	case "application/x-www-form-urlencoded":
+		err = request.ParseForm()
+	case "multipart/form-data":
+		err = request.ParseMultipartForm(multipartMemory)
+	default:
+		err = ErrUnsupportedMediaType
+	}
+
+	if err != nil && err != io.EOF {
+		return &BindingError{Source: "body", Err: err}
+	}
+
+	return nil
+}
+
+// bindFields populates the tagged fields of the struct
+func (c *Context) bindFields(value reflect.Value) error {
+	valueType := value.Type()
+
+	for i := 0; i < valueType.NumField(); i++ {
+		field, fieldValue := valueType.Field(i), value.Field(i)
+
+		// Embedded structs are populated as if the fields were declared directly
+		if field.Anonymous && fieldValue.Kind() == reflect.Struct {
+			if err := c.bindFields(fieldValue); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if !field.IsExported() {
+			continue
+		}
+
+		for _, source := range bindingTags {
+			key, ok := field.Tag.Lookup(source)
+			if !ok || key == "" || key == "-" {
+				continue
+			}
+
+			values := c.bindingValues(source, key)
+			if len(values) == 0 {
+				continue
+			}
+
+			if err := setField(fieldValue, values); err != nil {
+				return &BindingError{field.Name, source, key, values[0], err}
+			}
+		}
+	}
+
+	return nil
+}
+
+// bindingValues returns the values for the key in the given source
+func (c *Context) bindingValues(source, key string) []string {
+	switch source {
+	case "form":
+		if c.request.PostForm == nil {
+			return nil
+		}
+		return c.request.PostForm[key]
+	case "query":
+		return c.request.URL.Query()[key]
+	case "header":
+		return c.request.Header.Values(key)
+	case "param":
+		if ok, value := c.params.GetByName(key); ok {
+			return []string{value}
+		}
+	}
+
+	return nil
+}
+
+var (
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	durationType        = reflect.TypeOf(time.Duration(0))
+)
+
+// setField converts the values to the type of the field and sets it.
+// Slices receive all values, other types the first one. []byte receives the raw value
+func setField(field reflect.Value, values []string) error {
+	if field.Kind() == reflect.Slice && field.Type().Elem().Kind() != reflect.Uint8 {
+		slice := reflect.MakeSlice(field.Type(), len(values), len(values))
+		for i, value := range values {
+			if err := setValue(slice.Index(i), value); err != nil {
+				return err
+			}
+		}
+		field.Set(slice)
+
+		return nil
+	}
+
+	return setValue(field, values[0])
+}
+
+func setValue(field reflect.Value, value string) error {
+	if field.Kind() == reflect.Ptr {
+		pointer := reflect.New(field.Type().Elem())
+		if err := setValue(pointer.Elem(), value); err != nil {
+			return err
+		}
+		field.Set(pointer)
+
+		return nil
+	}
+
+	// Covers time.Time among others, which is parsed as RFC 3339
+	if reflect.PointerTo(field.Type()).Implements(textUnmarshalerType) {
+		return field.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(value))
+	}
+
+	if field.Type() == durationType {
+		duration, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(duration))
+
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(value, 10, field.Type().Bits())
+		if err != nil {
+			return unwrapNumError(err)
+		}
+		field.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(value, 10, field.Type().Bits())
+		if err != nil {
+			return unwrapNumError(err)
+		}
+		field.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, field.Type().Bits())
+		if err != nil {
+			return unwrapNumError(err)
+		}
+		field.SetFloat(f)
+	case reflect.Slice:
+		// Only []byte gets here, as setField binds the elements of other slices one by one
+		if field.Type().Elem().Kind() != reflect.Uint8 {
+			return fmt.Errorf("unsupported field type %s", field.Type())
+		}
+		field.SetBytes([]byte(value))
+	default:
+		return fmt.Errorf("unsupported field type %s", field.Type())
+	}
+
+	return nil
+}
@@ -0,0 +1,143 @@
+package fit
+
+import (
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Renderer renders the data as the media type it's registered for using Router.Renderer
+type Renderer func(c *Context, data interface{}, status ...int)
+
+type renderer struct {
+	mediaType string
+	render    Renderer
+}
+
+// defaultRenderers returns the renderers registered on new routers, JSON being the default
+func defaultRenderers() []renderer {
+	return []renderer{
+		{"application/json", (*Context).JSON},
+		{"application/xml", (*Context).XML},
+	}
+}
+
+// Renderer registers the renderer for the media type used by Context.Negotiate, replacing any existing one.
+// The first registered renderer is the default, used when the client accepts anything
+func (r *Router) Renderer(mediaType string, render Renderer) {
+	mediaType = strings.ToLower(mediaType)
+	for i := range r.renderers {
+		if r.renderers[i].mediaType == mediaType {
+			r.renderers[i].render = render
+			return
+		}
+	}
+
+	r.renderers = append(r.renderers, renderer{mediaType, render})
+}
+
+// Negotiate renders the data using the renderer best matching the Accept header, with the supplied status code
+// Status code is optional and will use the default code set by the setStatus() function http.StatusOK => 200
+// If no renderer is acceptable, the NotAcceptable handler of the router is called (406)
+func (c *Context) Negotiate(data interface{}, status ...int) {
+	if !c.writable() {
+		return
+	}
+
+	renderers := defaultRenderers()
+	if c.router != nil {
+		renderers = c.router.renderers
+	}
+
+	c.writer.Header().Add("Vary", "Accept")
+
+	if render := negotiate(c.request.Header.Get("Accept"), renderers); render != nil {
+		render(c, data, status...)
+		return
+	}
+
+	c.status = http.StatusNotAcceptable
+	if c.router != nil && c.router.NotAcceptable != nil {
+		c.router.NotAcceptable(c)
+		return
+	}
+
+	notAcceptableHandler()(c)
+}
+
+// negotiate returns the renderer with the highest quality in the Accept header.
+// Renderers with the same quality are preferred in order of registration
+func negotiate(accept string, renderers []renderer) Renderer {
+	if len(renderers) == 0 {
+		return nil
+	}
+
+	if strings.TrimSpace(accept) == "" {
+		return renderers[0].render
+	}
+
+	ranges := parseAccept(accept)
+
+	var best Renderer
+	bestQuality := 0.0
+	for _, renderer := range renderers {
+		if quality := acceptQuality(renderer.mediaType, ranges); quality > bestQuality {
+			best, bestQuality = renderer.render, quality
+		}
+	}
+
+	return best
+}
+
+type mediaRange struct {
+	mediaType string
+	quality   float64
+}
+
+// parseAccept parses the media ranges of the Accept header, ignoring invalid ones
+func parseAccept(accept string) []mediaRange {
+	var ranges []mediaRange
+
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+
+		quality := 1.0
+		if q, ok := params["q"]; ok {
+			if quality, err = strconv.ParseFloat(q, 64); err != nil {
+				continue
+			}
+		}
+
+		ranges = append(ranges, mediaRange{mediaType, quality})
+	}
+
+	return ranges
+}
+
+// acceptQuality returns the quality of the most specific media range matching the media type, or 0
+func acceptQuality(mediaType string, ranges []mediaRange) float64 {
+	mainType, _, _ := strings.Cut(mediaType, "/")
+	quality, specificity := 0.0, -1
+
+	for _, r := range ranges {
+		matchSpecificity := -1
+		switch r.mediaType {
+		case mediaType:
+			matchSpecificity = 2
+		case mainType + "/*":
+			matchSpecificity = 1
+		case "*/*":
+			matchSpecificity = 0
+		}
+
+		if matchSpecificity > specificity {
+			quality, specificity = r.quality, matchSpecificity
+		}
+	}
+
+	return quality
+}
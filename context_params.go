@@ -1,5 +1,13 @@
 package fit
 
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
 type Parameters struct {
 	stack []parameter
 }
@@ -23,3 +31,190 @@ func (p Parameters) GetByName(name string) (bool, string) {
 	}
 	return false, ""
 }
+
+// ParameterError is returned by the typed accessors, when a parameter is missing or can't be converted
+type ParameterError struct {
+	Name  string
+	Value string
+	Err   error
+}
+
+func (e *ParameterError) Error() string {
+	if e.Err == errMissingParameter {
+		return fmt.Sprintf("parameter '%s' is missing", e.Name)
+	}
+
+	return fmt.Sprintf("parameter '%s' with value '%s' is invalid: %s", e.Name, e.Value, e.Err)
+}
+
+func (e *ParameterError) Unwrap() error {
+	return e.Err
+}
+
+var (
+	errMissingParameter = errors.New("missing parameter")
+	errInvalidUUID      = errors.New("invalid UUID format")
+)
+
+// lookup returns the value of the parameter, or an error if it doesn't exist
+func (p Parameters) lookup(name string) (string, error) {
+	ok, value := p.GetByName(name)
+	if !ok {
+		return "", &ParameterError{name, "", errMissingParameter}
+	}
+
+	return value, nil
+}
+
+// convert looks up the parameter and converts it, wrapping any error in a ParameterError
+func convert[T any](p Parameters, name string, conversion func(string) (T, error)) (T, error) {
+	value, err := p.lookup(name)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+
+	converted, err := conversion(value)
+	if err != nil {
+		return converted, &ParameterError{name, value, unwrapNumError(err)}
+	}
+
+	return converted, nil
+}
+
+// Int returns the parameter converted to an int
+func (p Parameters) Int(name string) (int, error) {
+	return convert(p, name, strconv.Atoi)
+}
+
+// Int64 returns the parameter converted to an int64
+func (p Parameters) Int64(name string) (int64, error) {
+	return convert(p, name, func(value string) (int64, error) {
+		return strconv.ParseInt(value, 10, 64)
+	})
+}
+
+// Uint returns the parameter converted to an uint
+func (p Parameters) Uint(name string) (uint, error) {
+	return convert(p, name, func(value string) (uint, error) {
+		u, err := strconv.ParseUint(value, 10, 0)
+		return uint(u), err
+	})
+}
+
+// Float returns the parameter converted to a float64
+func (p Parameters) Float(name string) (float64, error) {
+	return convert(p, name, func(value string) (float64, error) {
+		return strconv.ParseFloat(value, 64)
+	})
+}
+
+// Bool returns the parameter converted to a bool. Accepts the same values as strconv.ParseBool
+func (p Parameters) Bool(name string) (bool, error) {
+	return convert(p, name, strconv.ParseBool)
+}
+
+// UUID returns the parameter parsed as an UUID in the canonical form xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx
+func (p Parameters) UUID(name string) ([16]byte, error) {
+	return convert(p, name, parseUUID)
+}
+
+// Time returns the parameter parsed as time using the given layout, e.g. time.RFC3339
+func (p Parameters) Time(name, layout string) (time.Time, error) {
+	return convert(p, name, func(value string) (time.Time, error) {
+		return time.Parse(layout, value)
+	})
+}
+
+// unwrapNumError strips the strconv context, as the surrounding error already describes the value
+func unwrapNumError(err error) error {
+	var numError *strconv.NumError
+	if errors.As(err, &numError) {
+		return numError.Err
+	}
+
+	return err
+}
+
+func parseUUID(value string) (uuid [16]byte, err error) {
+	if len(value) != 36 || value[8] != '-' || value[13] != '-' || value[18] != '-' || value[23] != '-' {
+		return uuid, errInvalidUUID
+	}
+
+	hexValue := value[:8] + value[9:13] + value[14:18] + value[19:23] + value[24:]
+	if _, err := hex.Decode(uuid[:], []byte(hexValue)); err != nil {
+		return uuid, errInvalidUUID
+	}
+
+	return uuid, nil
+}
+
+// MustInt returns the parameter converted to an int.
+// If the conversion fails, the error is passed to the error handler (400 pr. default) and false is returned
+func (c *Context) MustInt(name string) (int, bool) {
+	value, err := c.params.Int(name)
+	if err != nil {
+		c.Error(err)
+	}
+	return value, err == nil
+}
+
+// MustInt64 returns the parameter converted to an int64.
+// If the conversion fails, the error is passed to the error handler (400 pr. default) and false is returned
+func (c *Context) MustInt64(name string) (int64, bool) {
+	value, err := c.params.Int64(name)
+	if err != nil {
+		c.Error(err)
+	}
+	return value, err == nil
+}
+
+// MustUint returns the parameter converted to an uint.
+// If the conversion fails, the error is passed to the error handler (400 pr. default) and false is returned
+func (c *Context) MustUint(name string) (uint, bool) {
+	value, err := c.params.Uint(name)
+	if err != nil {
+		c.Error(err)
+	}
+	return value, err == nil
+}
+
+// MustFloat returns the parameter converted to a float64.
+// If the conversion fails, the error is passed to the error handler (400 pr. default) and false is returned
+func (c *Context) MustFloat(name string) (float64, bool) {
+	value, err := c.params.Float(name)
+	if err != nil {
+		c.Error(err)
+	}
+	return value, err == nil
+}
+
+// MustBool returns the parameter converted to a bool.
+// If the conversion fails, the error is passed to the error handler (400 pr. default) and false is returned
+func (c *Context) MustBool(name string) (bool, bool) {
+	value, err := c.params.Bool(name)
+	if err != nil {
+		c.Error(err)
+	}
+	return value, err == nil
+}
+
+// MustUUID returns the parameter parsed as an UUID.
+// If the parsing fails, the error is passed to the error handler (400 pr. default) and false is returned
+func (c *Context) MustUUID(name string) ([16]byte, bool) {
+	value, err := c.params.UUID(name)
+	if err != nil {
+		c.Error(err)
+	}
+	return value, err == nil
+}
+
+// MustTime returns the parameter parsed as time using the given layout.
+// If the parsing fails, the error is passed to the error handler (400 pr. default) and false is returned
+func (c *Context) MustTime(name, layout string) (time.Time, bool) {
+	value, err := c.params.Time(name, layout)
+	if err != nil {
+		c.Error(err)
+	}
+	return value, err == nil
+}
@@ -0,0 +1,147 @@
+package fit
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"regexp"
+)
+
+// Content types set by the response helpers
+const (
+	contentTypeJSON       = "application/json; charset=utf-8"
+	contentTypeJavaScript = "application/javascript; charset=utf-8"
+	contentTypeXML        = "application/xml; charset=utf-8"
+	contentTypeHTML       = "text/html; charset=utf-8"
+	contentTypeText       = "text/plain; charset=utf-8"
+)
+
+// String writes the text to body as text/plain, with the supplied status code
+// Status code is optional and will use the default code set by the setStatus() function http.StatusOK => 200
+func (c *Context) String(text string, status ...int) {
+	c.Blob(contentTypeText, []byte(text), status...)
+}
+
+// HTML writes the html to body as text/html, with the supplied status code
+// Status code is optional and will use the default code set by the setStatus() function http.StatusOK => 200
+func (c *Context) HTML(html string, status ...int) {
+	c.Blob(contentTypeHTML, []byte(html), status...)
+}
+
+// XML tries to encode the given interface as XML using xml.Marshal() and write the result to body, with the supplied status code
+// Status code is optional and will use the default code set by the setStatus() function http.StatusOK => 200
+// If it fails, the error is passed to the error handler of the router
+func (c *Context) XML(data interface{}, status ...int) {
+	b, err := xml.Marshal(data)
+
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Blob(contentTypeXML, append([]byte(xml.Header), b...), status...)
+}
+
+// Callbacks accepted by JSONP, e.g. "callback" or "jQuery.cb_1", preventing script injection
+var jsonpCallback = regexp.MustCompile(`^[A-Za-z_$][\w$.]*$`)
+
+// JSONP encodes the given interface as JSON, wrapped in a call to the callback, with the supplied status code
+// Status code is optional and will use the default code set by the setStatus() function http.StatusOK => 200
+// If the callback is not a valid identifier, an HTTPError with http.StatusBadRequest is passed to the error handler of the router.
+// If encoding fails, the error is passed to the error handler of the router
+func (c *Context) JSONP(callback string, data interface{}, status ...int) {
+	if !jsonpCallback.MatchString(callback) {
+		c.Error(NewHTTPError(http.StatusBadRequest, "Invalid JSONP callback"))
+		return
+	}
+
+	b, err := json.Marshal(data)
+
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	body := make([]byte, 0, len(callback)+len(b)+3)
+	body = append(append(append(append(body, callback...), '('), b...), ");"...)
+
+	c.writer.Header().Set("X-Content-Type-Options", "nosniff")
+	c.Blob(contentTypeJavaScript, body, status...)
+}
+
+// Blob writes the data to body with the given content type and the supplied status code
+// Status code is optional and will use the default code set by the setStatus() function http.StatusOK => 200
+func (c *Context) Blob(contentType string, data []byte, status ...int) {
+	if !c.writable() {
+		return
+	}
+
+	c.writer.Header().Set("Content-Type", contentType)
+	c.setStatus(status...)
+	c.writer.Write(data)
+}
+
+// Stream copies the reader to body with the given content type and the supplied status code
+// Status code is optional and will use the default code set by the setStatus() function http.StatusOK => 200
+func (c *Context) Stream(contentType string, reader io.Reader, status ...int) error {
+	if !c.writable() {
+		return ErrResponseWritten
+	}
+
+	c.writer.Header().Set("Content-Type", contentType)
+	c.setStatus(status...)
+	_, err := io.Copy(c.writer, reader)
+
+	return err
+}
+
+// NoContent answers with http.StatusNoContent and an empty body
+func (c *Context) NoContent() {
+	if !c.writable() {
+		return
+	}
+
+	c.setStatus(http.StatusNoContent)
+}
+
+// Redirect redirects the request to the url, with the supplied status code
+// Status code is optional and defaults to http.StatusFound => 302
+func (c *Context) Redirect(url string, status ...int) {
+	if !c.writable() {
+		return
+	}
+
+	c.status = http.StatusFound
+	if len(status) > 0 {
+		c.status = status[0]
+	}
+
+	http.Redirect(c.writer, c.request, url, c.status)
+}
+
+// File serves the file at the given path using http.ServeFile,
+// which handles content types, ranges and conditional requests
+func (c *Context) File(path string) {
+	if !c.writable() {
+		return
+	}
+
+	http.ServeFile(c.writer, c.request, path)
+}
+
+// Attachment serves the file at the given path, prompting the client to download it with the given name
+func (c *Context) Attachment(path, name string) {
+	if !c.writable() {
+		return
+	}
+
+	if name == "" {
+		name = filepath.Base(path)
+	}
+
+	c.writer.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": name}))
+	c.File(path)
+}
@@ -1,12 +1,23 @@
 package fit
 
+import "context"
+
 type Shared struct {
 	values map[string]interface{}
 }
 
+// SharedKey is the key of shared values in the context of the request, e.g. ctx.Value(fit.SharedKey("user")).
+type SharedKey string
+
+// Shared returns the values shared between middleware(s).
+// The values are also exposed to the context of the request, using SharedKey or the typed Key
 func (c *Context) Shared() Shared {
 	if c.shared.values == nil {
 		c.shared = Shared{make(map[string]interface{})}
+
+		if c.request != nil {
+			c.request = c.request.WithContext(sharedContext{c.request.Context(), c.shared.values})
+		}
 	}
 
 	return c.shared
@@ -20,3 +31,63 @@ func (s Shared) Get(key string) (ok bool, value interface{}) {
 func (s Shared) Set(key string, value interface{}) {
 	s.values[key] = value
 }
+
+// Key is a typed key for shared values, avoiding unchecked type assertions.
+// Create it once using NewKey, e.g. var userKey = fit.NewKey[*User]("user")
+type Key[T any] struct {
+	name string
+}
+
+// NewKey returns a typed key for the shared value with the given name
+func NewKey[T any](name string) Key[T] {
+	return Key[T]{name}
+}
+
+// Get returns the shared value, and false if it's not set or has another type.
+// Reading the values directly, nothing is allocated when no values are shared
+func (k Key[T]) Get(c *Context) (T, bool) {
+	value := c.shared.values[k.name]
+	typed, ok := value.(T)
+
+	return typed, ok
+}
+
+// Set sets the shared value
+func (k Key[T]) Set(c *Context, value T) {
+	c.Shared().Set(k.name, value)
+}
+
+// FromContext returns the shared value from a context, e.g. the context of the request in downstream libraries
+func (k Key[T]) FromContext(ctx context.Context) (T, bool) {
+	typed, ok := ctx.Value(k).(T)
+	return typed, ok
+}
+
+func (k Key[T]) sharedName() string {
+	return k.name
+}
+
+// sharedContext exposes the shared values to the context of the request.
+// The values are looked up when requested, so values set later are visible as well
+type sharedContext struct {
+	context.Context
+	values map[string]interface{}
+}
+
+func (ctx sharedContext) Value(key interface{}) interface{} {
+	name, isShared := "", false
+	switch k := key.(type) {
+	case SharedKey:
+		name, isShared = string(k), true
+	case interface{ sharedName() string }:
+		name, isShared = k.sharedName(), true
+	}
+
+	if isShared {
+		if value, ok := ctx.values[name]; ok {
+			return value
+		}
+	}
+
+	return ctx.Context.Value(key)
+}
@@ -1,16 +1,26 @@
 package fit
 
 import (
+	"context"
+	"errors"
 	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 )
 
 var (
-	context = newContext()
+	testContext = newContext()
 )
 
 func TestContextInitialization(t *testing.T) {
-	if context == nil {
+	if testContext == nil {
 		t.Fatal("Context was never initialized")
 	}
 }
@@ -34,17 +44,17 @@ func HandlerTest1(t *testing.T) ResponseHandler {
 
 func HandlerTest2(t *testing.T) ResponseHandler {
 	return func(c *Context) {
-		_, teststring := context.Shared().Get("teststring")
+		_, teststring := testContext.Shared().Get("teststring")
 		if teststring != "Some string value" {
 			t.Errorf("Shared value did not get passed to next function, expected '%s', got '%s'.", "teststring", teststring)
 		}
-		_, testinteger := context.Shared().Get("testinteger")
+		_, testinteger := testContext.Shared().Get("testinteger")
 		if testinteger != 5325 {
 			t.Errorf("Shared value did not get passed to next function, expected '%d', got '%d'.", 5325, testinteger)
 		}
 
 		// Should return false, as were calling a value that was never set
-		ok, _ := context.Shared().Get("thisdoesnotexist")
+		ok, _ := testContext.Shared().Get("thisdoesnotexist")
 
 		if ok {
 			t.Errorf("Should not exist. Expected '%t', got '%t'", false, ok)
@@ -60,30 +70,30 @@ func HandlerTest2(t *testing.T) ResponseHandler {
 
 func TestContextMiddlewareAndShared(t *testing.T) {
 	handlers := []ResponseHandler{HandlerTest1(t), HandlerTest2(t)}
-	context.handlers = handlers
-	context.maxHandlers = len(handlers)
-	context.callByIndex(0)
+	testContext.handlers = handlers
+	testContext.maxHandlers = len(handlers)
+	testContext.callByIndex(0)
 }
 
 func TestContextParameters(t *testing.T) {
 	testParams := make([]parameter, 2)
 	testParams = append(testParams, parameter{"id", "22"})
 	testParams = append(testParams, parameter{"name", "John"})
-	context.params = Parameters{testParams}
+	testContext.params = Parameters{testParams}
 
-	ok, id := context.Parameters().GetByName("id")
+	ok, id := testContext.Parameters().GetByName("id")
 
 	if !ok || id != "22" {
 		t.Errorf("Value for 'id' was wrong. Expected '%s', got '%s'", "22", id)
 	}
 
-	ok2, name := context.Parameters().GetByName("name")
+	ok2, name := testContext.Parameters().GetByName("name")
 
 	if !ok2 || name != "John" {
 		t.Errorf("Value for 'name' was wrong. Expected '%s', got '%s'", "John", name)
 	}
 
-	exists, _ := context.Parameters().GetByName("doesnotexist")
+	exists, _ := testContext.Parameters().GetByName("doesnotexist")
 
 	if exists {
 		t.Error("Shouldn't be able to fetch value for 'doesnotexist'")
@@ -91,9 +101,665 @@ func TestContextParameters(t *testing.T) {
 }
 
 func TestContextStatus(t *testing.T) {
-	context.status = http.StatusTeapot
+	testContext.status = http.StatusTeapot
 
-	if context.Status() != http.StatusTeapot {
-		t.Errorf("Status is not correct, expected %d, got %d", http.StatusTeapot, context.Status())
+	if testContext.Status() != http.StatusTeapot {
+		t.Errorf("Status is not correct, expected %d, got %d", http.StatusTeapot, testContext.Status())
 	}
 }
+
+func TestContextTypedParameters(t *testing.T) {
+	params := Parameters{[]parameter{
+		{"id", "42"},
+		{"negative", "-7"},
+		{"price", "9.95"},
+		{"active", "true"},
+		{"uuid", "123e4567-e89b-12d3-a456-426614174000"},
+		{"date", "2020-01-02"},
+		{"name", "john"},
+	}}
+
+	if id, err := params.Int("id"); err != nil || id != 42 {
+		t.Errorf("Int is wrong. Expected %d, got %d (%v)", 42, id, err)
+	}
+
+	if negative, err := params.Int64("negative"); err != nil || negative != -7 {
+		t.Errorf("Int64 is wrong. Expected %d, got %d (%v)", -7, negative, err)
+	}
+
+	if _, err := params.Uint("negative"); err == nil {
+		t.Error("Uint should fail for negative values")
+	}
+
+	if price, err := params.Float("price"); err != nil || price != 9.95 {
+		t.Errorf("Float is wrong. Expected %f, got %f (%v)", 9.95, price, err)
+	}
+
+	if active, err := params.Bool("active"); err != nil || !active {
+		t.Errorf("Bool is wrong. Expected %t, got %t (%v)", true, active, err)
+	}
+
+	uuid := [16]byte{0x12, 0x3e, 0x45, 0x67, 0xe8, 0x9b, 0x12, 0xd3, 0xa4, 0x56, 0x42, 0x66, 0x14, 0x17, 0x40, 0x00}
+	if value, err := params.UUID("uuid"); err != nil || value != uuid {
+		t.Errorf("UUID is wrong. Expected %x, got %x (%v)", uuid, value, err)
+	}
+
+	if _, err := params.UUID("name"); err == nil {
+		t.Error("UUID should fail for invalid values")
+	}
+
+	if date, err := params.Time("date", "2006-01-02"); err != nil || !date.Equal(time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Time is wrong, got %s (%v)", date, err)
+	}
+
+	var parameterError *ParameterError
+	if _, err := params.Int("name"); !errors.As(err, &parameterError) || parameterError.Name != "name" {
+		t.Errorf("Expected a ParameterError for 'name', got %v", err)
+	}
+
+	if _, err := params.Int("doesnotexist"); !errors.As(err, &parameterError) || parameterError.Err != errMissingParameter {
+		t.Errorf("Expected a missing ParameterError for 'doesnotexist', got %v", err)
+	}
+}
+
+func TestContextMustParameters(t *testing.T) {
+	c := newContext()
+	w := httptest.NewRecorder()
+	c.writer, c.params = w, Parameters{[]parameter{{"id", "42"}, {"name", "john"}}}
+
+	if id, ok := c.MustInt("id"); !ok || id != 42 {
+		t.Errorf("MustInt is wrong. Expected %d, got %d", 42, id)
+	}
+
+	if w.Code != http.StatusOK || w.Body.Len() != 0 {
+		t.Error("MustInt should not respond on success")
+	}
+
+	if _, ok := c.MustInt("name"); ok {
+		t.Error("MustInt should fail for 'name'")
+	}
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Status is not correct, expected %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+type bindPagination struct {
+	Page    int  `query:"page"`
+	PerPage *int `query:"per_page"`
+}
+
+type bindTarget struct {
+	bindPagination
+	ID      uint          `param:"id"`
+	Token   string        `header:"X-Token"`
+	Tags    []string      `query:"tag"`
+	Timeout time.Duration `query:"timeout"`
+	Name    string        `json:"name" xml:"name" form:"name"`
+	Age     int           `json:"age" xml:"age" form:"age"`
+}
+
+func bindContext(method, target, contentType, body string) *Context {
+	c := newContext()
+	c.request = httptest.NewRequest(method, target, strings.NewReader(body))
+	if contentType != "" {
+		c.request.Header.Set("Content-Type", contentType)
+	}
+	c.request.Header.Set("X-Token", "secret")
+	c.params = Parameters{[]parameter{{"id", "7"}}}
+
+	return c
+}
+
+func TestContextBind(t *testing.T) {
+	perPage := 25
+	expected := bindTarget{bindPagination{2, &perPage}, 7, "secret", []string{"a", "b"}, time.Minute, "john", 40}
+	query := "/users/7?page=2&per_page=25&tag=a&tag=b&timeout=1m"
+
+	bodies := map[string]string{
+		"application/json; charset=utf-8":   `{"name": "john", "age": 40}`,
+		"application/xml":                   `<bindTarget><name>john</name><age>40</age></bindTarget>`,
+		"application/x-www-form-urlencoded": `name=john&age=40`,
+	}
+
+	for contentType, body := range bodies {
+		var target bindTarget
+		if err := bindContext("POST", query, contentType, body).Bind(&target); err != nil {
+			t.Errorf("Binding '%s' failed: %s", contentType, err)
+		}
+
+		if !reflect.DeepEqual(target, expected) {
+			t.Errorf("Binding '%s' is wrong. Expected %+v, got %+v", contentType, expected, target)
+		}
+	}
+}
+
+func TestContextBindErrors(t *testing.T) {
+	var target bindTarget
+	var bindingError *BindingError
+
+	err := bindContext("GET", "/users/7?page=first", "", "").Bind(&target)
+	if !errors.As(err, &bindingError) || bindingError.Field != "Page" || bindingError.Source != "query" {
+		t.Errorf("Expected a BindingError for the field 'Page', got %v", err)
+	}
+
+	err = bindContext("POST", "/users/7", "text/csv", "name,age").Bind(&target)
+	if !errors.Is(err, ErrUnsupportedMediaType) {
+		t.Errorf("Expected ErrUnsupportedMediaType, got %v", err)
+	}
+
+	err = bindContext("POST", "/users/7", "application/json", "{broken").Bind(&target)
+	if !errors.As(err, &bindingError) || bindingError.Source != "body" {
+		t.Errorf("Expected a BindingError for the body, got %v", err)
+	}
+
+	if err := bindContext("GET", "/users/7", "", "").Bind(target); err == nil {
+		t.Error("Binding to a non-pointer should fail")
+	}
+
+	var raw struct {
+		Token []byte `header:"X-Token"`
+	}
+	if err := bindContext("GET", "/users/7", "", "").Bind(&raw); err != nil || string(raw.Token) != "secret" {
+		t.Errorf("Expected []byte to receive the raw value, got '%s' (%v)", raw.Token, err)
+	}
+
+	var unsupported struct {
+		Tags map[string]string `query:"tags"`
+	}
+	err = bindContext("GET", "/users/7?tags=a", "", "").Bind(&unsupported)
+	if !errors.As(err, &bindingError) || bindingError.Field != "Tags" {
+		t.Errorf("Expected a BindingError for an unsupported field type, got %v", err)
+	}
+}
+
+type xmlMessage struct {
+	XMLName struct{} `xml:"m"`
+}
+
+func TestContextResponseHelpers(t *testing.T) {
+	tests := []struct {
+		name        string
+		respond     func(c *Context)
+		status      int
+		contentType string
+		body        string
+	}{
+		{"String", func(c *Context) { c.String("hello", http.StatusAccepted) }, http.StatusAccepted, contentTypeText, "hello"},
+		{"HTML", func(c *Context) { c.HTML("<p>hello</p>") }, http.StatusOK, contentTypeHTML, "<p>hello</p>"},
+		{"XML", func(c *Context) { c.XML(xmlMessage{}) }, http.StatusOK, contentTypeXML, "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<m></m>"},
+		{"JSONP", func(c *Context) { c.JSONP("cb", []int{1}) }, http.StatusOK, contentTypeJavaScript, "cb([1]);"},
+		{"Blob", func(c *Context) { c.Blob("image/png", []byte("png")) }, http.StatusOK, "image/png", "png"},
+		{"Stream", func(c *Context) { c.Stream("text/csv", strings.NewReader("a,b")) }, http.StatusOK, "text/csv", "a,b"},
+		{"NoContent", func(c *Context) { c.NoContent() }, http.StatusNoContent, "", ""},
+	}
+
+	for _, test := range tests {
+		c := newContext()
+		w := httptest.NewRecorder()
+		c.writer, c.request = w, httptest.NewRequest("GET", "/", nil)
+		test.respond(c)
+
+		if w.Code != test.status || c.Status() != test.status {
+			t.Errorf("%s: status is wrong. Expected %d, got %d (context %d)", test.name, test.status, w.Code, c.Status())
+		}
+
+		if contentType := w.Header().Get("Content-Type"); contentType != test.contentType {
+			t.Errorf("%s: content type is wrong. Expected '%s', got '%s'", test.name, test.contentType, contentType)
+		}
+
+		if w.Body.String() != test.body {
+			t.Errorf("%s: body is wrong. Expected '%s', got '%s'", test.name, test.body, w.Body.String())
+		}
+	}
+}
+
+func TestContextJSONPCallback(t *testing.T) {
+	r := NewRouter()
+	r.Get("/jsonp", func(c *Context) {
+		c.JSONP(c.Request().URL.Query().Get("callback"), map[string]int{"a": 1})
+	})
+
+	tests := []struct {
+		callback string
+		status   int
+	}{
+		{"jQuery.cb_1", http.StatusOK},
+		{"alert(document.cookie)//", http.StatusBadRequest},
+		{"", http.StatusBadRequest},
+	}
+
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", "/jsonp?callback="+url.QueryEscape(test.callback), nil))
+
+		if w.Code != test.status {
+			t.Errorf("Status for callback '%s' is wrong. Expected %d, got %d '%s'", test.callback, test.status, w.Code, w.Body.String())
+		}
+
+		if test.status == http.StatusOK && w.Header().Get("X-Content-Type-Options") != "nosniff" {
+			t.Error("JSONP should set X-Content-Type-Options: nosniff")
+		}
+
+		if test.status != http.StatusOK && strings.Contains(w.Body.String(), "document.cookie") {
+			t.Errorf("Invalid callback should not be written, got '%s'", w.Body.String())
+		}
+	}
+}
+
+func TestContextRedirectAndFiles(t *testing.T) {
+	c := newContext()
+	w := httptest.NewRecorder()
+	c.writer, c.request = w, httptest.NewRequest("GET", "/old", nil)
+	c.Redirect("/new", http.StatusMovedPermanently)
+
+	if w.Code != http.StatusMovedPermanently || c.Status() != http.StatusMovedPermanently || w.Header().Get("Location") != "/new" {
+		t.Errorf("Redirect is wrong, got status %d to '%s'", w.Code, w.Header().Get("Location"))
+	}
+
+	path := filepath.Join(t.TempDir(), "report.txt")
+	if err := os.WriteFile(path, []byte("report"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	c = newContext()
+	w = httptest.NewRecorder()
+	c.writer, c.request = &responseWriter{ResponseWriter: w, c: c}, httptest.NewRequest("GET", "/report", nil)
+	c.Attachment(path, "")
+
+	if c.Status() != http.StatusOK || w.Body.String() != "report" {
+		t.Errorf("Attachment is wrong, got status %d with body '%s'", c.Status(), w.Body.String())
+	}
+
+	if disposition := w.Header().Get("Content-Disposition"); disposition != "attachment; filename=report.txt" {
+		t.Errorf("Content-Disposition is wrong, got '%s'", disposition)
+	}
+
+	c = newContext()
+	w = httptest.NewRecorder()
+	c.writer, c.request = &responseWriter{ResponseWriter: w, c: c}, httptest.NewRequest("GET", "/missing", nil)
+	c.File(path + ".missing")
+
+	if c.Status() != http.StatusNotFound {
+		t.Errorf("Status of a missing file is wrong. Expected %d, got %d", http.StatusNotFound, c.Status())
+	}
+}
+
+func TestContextNegotiate(t *testing.T) {
+	r := NewRouter()
+	r.Renderer("text/csv", func(c *Context, data interface{}, status ...int) {
+		c.Blob("text/csv", []byte(strings.Join(data.([]string), ",")), status...)
+	})
+	r.Get("/negotiate", func(c *Context) {
+		c.Negotiate([]string{"a", "b"}, http.StatusAccepted)
+	})
+
+	tests := []struct {
+		accept      string
+		status      int
+		contentType string
+	}{
+		{"", http.StatusAccepted, contentTypeJSON},
+		{"*/*", http.StatusAccepted, contentTypeJSON},
+		{"text/csv", http.StatusAccepted, "text/csv"},
+		{"application/json;q=0.5, text/csv;q=0.8", http.StatusAccepted, "text/csv"},
+		{"text/*, application/json;q=0.9", http.StatusAccepted, "text/csv"},
+		{"application/xml, */*;q=0.1", http.StatusAccepted, contentTypeXML},
+		{"text/csv;q=0, application/json;q=0.1", http.StatusAccepted, contentTypeJSON},
+		{"application/msgpack", http.StatusNotAcceptable, contentTypeJSON},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest("GET", "/negotiate", nil)
+		req.Header.Set("Accept", test.accept)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != test.status {
+			t.Errorf("Status for '%s' is wrong. Expected %d, got %d", test.accept, test.status, w.Code)
+		}
+
+		if contentType := w.Header().Get("Content-Type"); contentType != test.contentType {
+			t.Errorf("Content-Type for '%s' is wrong. Expected '%s', got '%s'", test.accept, test.contentType, contentType)
+		}
+	}
+}
+
+func TestContextIsContext(t *testing.T) {
+	type key string
+
+	var c context.Context = newContext()
+	if c.Err() != nil || c.Done() != nil {
+		t.Error("Context without request should behave like context.Background()")
+	}
+
+	fc := newContext()
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), key("user"), "john"))
+	fc.request = httptest.NewRequest("GET", "/", nil).WithContext(ctx)
+
+	if fc.Value(key("user")) != "john" {
+		t.Errorf("Value should be delegated to the request context, got %v", fc.Value(key("user")))
+	}
+
+	cancel()
+	<-fc.Done()
+	if fc.Err() != context.Canceled {
+		t.Errorf("Err should be delegated to the request context, got %v", fc.Err())
+	}
+}
+
+func TestContextTimeout(t *testing.T) {
+	r := NewRouter()
+	calledAfterTimeout := false
+
+	r.Get("/slow", func(c *Context) {
+		<-c.Done()
+		c.Next()
+	}, func(c *Context) {
+		calledAfterTimeout = true
+	}).Timeout(10 * time.Millisecond)
+
+	api := r.Group("/api").Timeout(10 * time.Millisecond)
+	api.Get("/slow", func(c *Context) {
+		if _, ok := c.Deadline(); !ok {
+			t.Error("Group timeout should set a deadline")
+		}
+		<-c.Done()
+	})
+	api.Get("/fast", func(c *Context) {
+		c.JSON("fast")
+	})
+
+	for _, path := range []string{"/slow", "/api/slow"} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+
+		if w.Code != http.StatusGatewayTimeout {
+			t.Errorf("Status for '%s' is wrong. Expected %d, got %d", path, http.StatusGatewayTimeout, w.Code)
+		}
+	}
+
+	if calledAfterTimeout {
+		t.Error("Next should not call handlers after the timeout")
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/api/fast", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("Status for '/api/fast' is wrong. Expected %d, got %d", http.StatusOK, w.Code)
+	}
+}
+
+func TestContextTimeoutIgnoringContext(t *testing.T) {
+	r := NewRouter()
+	release := make(chan struct{})
+	defer close(release)
+
+	r.Get("/late", func(c *Context) {
+		<-release
+		c.String("late")
+	}).Timeout(20 * time.Millisecond)
+	r.Post("/late", func(c *Context) {
+		c.String("posted")
+	})
+
+	var afterCalls int
+	r.After(func(c *Context) {
+		afterCalls++
+	})
+
+	start := time.Now()
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/late", nil))
+
+	if w.Code != http.StatusGatewayTimeout || time.Since(start) > time.Second {
+		t.Errorf("Expected %d on time, got %d '%s' after %s", http.StatusGatewayTimeout, w.Code, w.Body.String(), time.Since(start))
+	}
+
+	if afterCalls != 1 {
+		t.Errorf("After handlers should run once, got %d", afterCalls)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("POST", "/late", nil))
+	if w.Code != http.StatusOK || w.Body.String() != "posted" {
+		t.Errorf("Routes without timeout should not be affected, got %d '%s'", w.Code, w.Body.String())
+	}
+}
+
+func TestContextTimeoutPanics(t *testing.T) {
+	r := NewRouter()
+	logger := &recordingLogger{}
+	r.Log = logger
+
+	release := make(chan bool)
+	r.Get("/panic", func(c *Context) {
+		panic("in time")
+	}).Timeout(time.Second)
+	r.Get("/late-panic", func(c *Context) {
+		<-release
+		panic("too late")
+	}).Timeout(10 * time.Millisecond)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/panic", nil))
+
+	if w.Code != http.StatusInternalServerError || len(logger.records) != 1 {
+		t.Fatalf("Panic should be recovered and logged, got %d with %v", w.Code, logger.records)
+	}
+
+	if stack, _ := logger.records[0].fields["stack"].(string); !strings.Contains(stack, "TestContextTimeoutPanics") {
+		t.Errorf("Stack trace should contain the panicking handler, got '%s'", stack)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/late-panic", nil))
+	close(release)
+
+	if records := logger.waitFor(2); w.Code != http.StatusGatewayTimeout || len(records) != 2 || records[1].fields["panic"] == nil {
+		t.Errorf("Panic after the timeout should be logged, got %d with %v", w.Code, records)
+	}
+}
+
+func TestContextTimeoutBufferedResponse(t *testing.T) {
+	r := NewRouter()
+	r.Get("/fast", func(c *Context) {
+		c.Shared().Set("user", "brian")
+		c.Writer().Header().Set("X-Fast", "yes")
+		c.String("fast", http.StatusCreated)
+	}).Timeout(time.Second)
+
+	var user interface{}
+	r.After(func(c *Context) {
+		_, user = c.Shared().Get("user")
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/fast", nil))
+
+	if w.Code != http.StatusCreated || w.Body.String() != "fast" || w.Header().Get("X-Fast") != "yes" {
+		t.Errorf("Buffered response was not written, got %d '%s'", w.Code, w.Body.String())
+	}
+
+	if user != "brian" {
+		t.Errorf("Shared values should be visible to the after handlers, got %v", user)
+	}
+}
+
+func TestContextTypedKeys(t *testing.T) {
+	userKey, countKey := NewKey[string]("user"), NewKey[int]("count")
+
+	c := newContext()
+	c.request = httptest.NewRequest("GET", "/", nil)
+
+	if _, ok := userKey.Get(c); ok {
+		t.Error("Key should not exist before it's set")
+	}
+
+	if allocs := testing.AllocsPerRun(10, func() { userKey.Get(c) }); allocs != 0 || c.shared.values != nil {
+		t.Errorf("Reading a key should not allocate the shared values, got %.0f allocations", allocs)
+	}
+
+	userKey.Set(c, "john")
+	c.Shared().Set("count", "not an int")
+
+	if user, ok := userKey.Get(c); !ok || user != "john" {
+		t.Errorf("Typed value is wrong. Expected '%s', got '%s'", "john", user)
+	}
+
+	if _, ok := countKey.Get(c); ok {
+		t.Error("Get should fail when the value has another type")
+	}
+
+	// The values are propagated to the context of the request
+	ctx := c.Request().Context()
+	if user, ok := userKey.FromContext(ctx); !ok || user != "john" {
+		t.Errorf("Typed value in the request context is wrong. Expected '%s', got '%s'", "john", user)
+	}
+
+	countKey.Set(c, 2)
+	if count, ok := countKey.FromContext(c); !ok || count != 2 {
+		t.Errorf("Values set later should be visible in the context. Expected %d, got %d", 2, count)
+	}
+
+	if ctx.Value(SharedKey("user")) != "john" {
+		t.Errorf("Value for SharedKey is wrong. Expected '%s', got '%v'", "john", ctx.Value(SharedKey("user")))
+	}
+}
+
+func TestContextAbort(t *testing.T) {
+	r := NewRouter()
+	var calls []string
+
+	r.Before(func(c *Context) {
+		calls = append(calls, "before")
+		c.Next()
+	})
+	r.After(func(c *Context) {
+		calls = append(calls, "after:"+strconv.FormatBool(c.IsAborted()))
+		c.Next()
+	}, func(c *Context) {
+		calls = append(calls, "after2")
+	})
+
+	r.Get("/abort", func(c *Context) {
+		c.AbortWithStatus(http.StatusUnauthorized)
+		if c.Next() {
+			t.Error("Next should not call handlers after abort")
+		}
+	}, func(c *Context) {
+		calls = append(calls, "handler")
+	})
+	r.Get("/abort-json", func(c *Context) {
+		c.AbortWithJSON("denied", http.StatusForbidden)
+	})
+	r.Get("/no-next", func(c *Context) {
+		c.JSON("no next")
+	})
+
+	tests := []struct {
+		path   string
+		status int
+		calls  []string
+	}{
+		{"/abort", http.StatusUnauthorized, []string{"before", "after:true", "after2"}},
+		{"/abort-json", http.StatusForbidden, []string{"before", "after:true", "after2"}},
+		{"/no-next", http.StatusOK, []string{"before", "after:false", "after2"}},
+	}
+
+	for _, test := range tests {
+		calls = nil
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", test.path, nil))
+
+		if w.Code != test.status {
+			t.Errorf("Status for '%s' is wrong. Expected %d, got %d", test.path, test.status, w.Code)
+		}
+
+		if !reflect.DeepEqual(calls, test.calls) {
+			t.Errorf("Calls for '%s' are wrong. Expected %s, got %s", test.path, test.calls, calls)
+		}
+	}
+}
+
+func TestAfterHandlersAlwaysRun(t *testing.T) {
+	r := NewRouter()
+	logger := &recordingLogger{}
+	r.Log = logger
+
+	var calls []string
+	r.After(func(c *Context) {
+		calls = append(calls, "after1")
+	}, func(c *Context) {
+		calls = append(calls, "after2")
+		panic("after2 failed")
+	}, func(c *Context) {
+		calls = append(calls, "after3:"+strconv.Itoa(c.Status()))
+	})
+
+	r.Get("/ok", func(c *Context) {
+		c.String("ok")
+	})
+	r.Get("/panic", func(c *Context) {
+		panic("handler failed")
+	})
+
+	tests := []struct {
+		path   string
+		status int
+		calls  []string
+	}{
+		{"/ok", http.StatusOK, []string{"after1", "after2", "after3:200"}},
+		{"/panic", http.StatusInternalServerError, []string{"after1", "after2", "after3:500"}},
+	}
+
+	for _, test := range tests {
+		calls = nil
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", test.path, nil))
+
+		if w.Code != test.status {
+			t.Errorf("Status for '%s' is wrong. Expected %d, got %d", test.path, test.status, w.Code)
+		}
+
+		if !reflect.DeepEqual(calls, test.calls) {
+			t.Errorf("Calls for '%s' are wrong. Expected %s, got %s", test.path, test.calls, calls)
+		}
+	}
+}
+
+func TestContextCopy(t *testing.T) {
+	r := NewRouter()
+	var copied *Context
+	r.Get("/users/:id", func(c *Context) {
+		c.Shared().Set("user", "brian")
+		if copied == nil {
+			copied = c.Copy()
+		}
+		c.String("ok")
+	})
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/users/1", nil))
+
+	// Serving a cancelled request, likely reusing the pooled Context
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/users/2", nil).WithContext(ctx))
+
+	if copied.Err() != nil {
+		t.Errorf("Copy should keep the context of its own request, got %v", copied.Err())
+	}
+
+	if _, id := copied.Parameters().GetByName("id"); id != "1" {
+		t.Errorf("Copy should keep the parameters of its own request, got '%s'", id)
+	}
+
+	if copied.Value(SharedKey("user")) != "brian" {
+		t.Errorf("Copy should expose the shared values, got %v", copied.Value(SharedKey("user")))
+	}
+
+	if !copied.Written() {
+		t.Error("Copy should not be able to write the response")
+	}
+	copied.String("ignored")
+}
@@ -0,0 +1,91 @@
+package fit
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// ErrResponseWritten is returned, or panicked with PanicOnWrite, when writing a response which has already been written
+var ErrResponseWritten = errors.New("fit: response has already been written")
+
+// WritePolicy decides what happens when a response helper is called after the response has been written
+type WritePolicy int
+
+const (
+	// DiscardWrite silently discards the write
+	DiscardWrite WritePolicy = iota
+
+	// PanicOnWrite panics with ErrResponseWritten, revealing double writes during development
+	PanicOnWrite
+)
+
+// HandlerFunc is an alternative to ResponseHandler, returning an error instead of rendering it.
+// Use Handle to adapt it to a ResponseHandler
+type HandlerFunc func(c *Context) error
+
+// Handle adapts the HandlerFunc to a ResponseHandler, passing returned errors to the error handler of the router
+func Handle(handler HandlerFunc) ResponseHandler {
+	return func(c *Context) {
+		if err := handler(c); err != nil {
+			c.Error(err)
+		}
+	}
+}
+
+// HTTPError is an error carrying the status code and message to respond with
+type HTTPError struct {
+	Code    int
+	Message string
+
+	// Internal error, not exposed to the client
+	Err error
+}
+
+// NewHTTPError returns an HTTPError with the status code, and the message if given.
+// The message defaults to the status text of the code
+func NewHTTPError(code int, message ...string) *HTTPError {
+	e := &HTTPError{Code: code, Message: http.StatusText(code)}
+	if len(message) > 0 {
+		e.Message = message[0]
+	}
+
+	return e
+}
+
+// WithError sets the internal error, e.g. for logging, and returns the HTTPError
+func (e *HTTPError) WithError(err error) *HTTPError {
+	e.Err = err
+	return e
+}
+
+func (e *HTTPError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%d %s: %s", e.Code, e.Message, e.Err)
+	}
+
+	return fmt.Sprintf("%d %s", e.Code, e.Message)
+}
+
+func (e *HTTPError) Unwrap() error {
+	return e.Err
+}
+
+// PanicError is passed to the error handler by the default panic handler, when a handler panics
+type PanicError struct {
+	// Value passed to panic
+	Recovered interface{}
+
+	// Stack trace of the panic
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic recovered: %v", e.Recovered)
+}
+
+// Unwrap returns the recovered value if it's an error
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Recovered.(error)
+	return err
+}
@@ -0,0 +1,147 @@
+package fit
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"runtime/debug"
+	"strings"
+	"testing"
+)
+
+func TestHandleErrors(t *testing.T) {
+	r := NewRouter()
+	r.Get("/http-error", Handle(func(c *Context) error {
+		return NewHTTPError(http.StatusForbidden, "Not yours")
+	}))
+	r.Get("/wrapped", Handle(func(c *Context) error {
+		return NewHTTPError(http.StatusConflict).WithError(errors.New("duplicate key"))
+	}))
+	r.Get("/unknown", Handle(func(c *Context) error {
+		return errors.New("database is down")
+	}))
+	r.Get("/parameter/:id", Handle(func(c *Context) error {
+		_, err := c.Parameters().Int("id")
+		return err
+	}))
+	r.Get("/unsupported", Handle(func(c *Context) error {
+		return &BindingError{Source: "body", Err: ErrUnsupportedMediaType}
+	}))
+	r.Get("/ok", Handle(func(c *Context) error {
+		c.JSON("ok")
+		return nil
+	}))
+
+	tests := []struct {
+		path    string
+		status  int
+		message string
+	}{
+		{"/http-error", http.StatusForbidden, "Not yours"},
+		{"/wrapped", http.StatusConflict, "Conflict"},
+		{"/unknown", http.StatusInternalServerError, "The server encountered an internal error."},
+		{"/parameter/abc", http.StatusBadRequest, "parameter 'id' with value 'abc' is invalid: invalid syntax"},
+		{"/unsupported", http.StatusUnsupportedMediaType, "binding body failed: unsupported media type"},
+		{"/does-not-exist", http.StatusNotFound, "The URL you've requested was not found."},
+	}
+
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", test.path, nil))
+
+		var response map[string]string
+		json.Unmarshal(w.Body.Bytes(), &response)
+
+		if w.Code != test.status || response["message"] != test.message {
+			t.Errorf("Response for '%s' is wrong. Expected %d '%s', got %d '%s'", test.path, test.status, test.message, w.Code, response["message"])
+		}
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/ok", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("Status for '/ok' is wrong. Expected %d, got %d", http.StatusOK, w.Code)
+	}
+}
+
+func TestCustomErrorHandlerRendersRouterErrors(t *testing.T) {
+	r := NewRouter()
+	r.Get("/only-get", func(c *Context) {})
+
+	var codes []int
+	r.ErrorHandler = func(c *Context, err error) {
+		var httpError *HTTPError
+		if errors.As(err, &httpError) {
+			codes = append(codes, httpError.Code)
+			c.String(httpError.Message, httpError.Code)
+		}
+	}
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/missing", nil))
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/only-get", nil))
+
+	if len(codes) != 2 || codes[0] != http.StatusNotFound || codes[1] != http.StatusMethodNotAllowed {
+		t.Errorf("Not found and method not allowed should be rendered by the error handler, got %v", codes)
+	}
+}
+
+func TestPanicRecovery(t *testing.T) {
+	r := NewRouter()
+	logger := &recordingLogger{}
+	r.Log = logger
+	r.Get("/panic", func(c *Context) {
+		c.Next()
+	}, func(c *Context) {
+		panic("something went wrong")
+	})
+
+	var loggedStatus int
+	r.Logger(func(c *Context) {
+		loggedStatus = c.Status()
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/panic", nil))
+
+	if w.Code != http.StatusInternalServerError || loggedStatus != http.StatusInternalServerError {
+		t.Errorf("Panic was not recovered. Expected %d, got %d (logged %d)", http.StatusInternalServerError, w.Code, loggedStatus)
+	}
+
+	if len(logger.records) != 1 || logger.records[0].level != "error" || logger.records[0].fields["panic"] != "something went wrong" {
+		t.Errorf("Recovered panic should be logged as an error, got %v", logger.records)
+	}
+
+	var recovered interface{}
+	var stack []byte
+	r.PanicHandler = func(c *Context, value interface{}) {
+		recovered, stack = value, debug.Stack()
+		c.String("recovered", http.StatusServiceUnavailable)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/panic", nil))
+
+	if recovered != "something went wrong" || w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Custom panic handler was not called, got '%v' with status %d", recovered, w.Code)
+	}
+
+	if !strings.Contains(string(stack), "TestPanicRecovery") {
+		t.Error("Stack trace should contain the panicking handler")
+	}
+}
+
+func TestPanicAbortHandler(t *testing.T) {
+	r := NewRouter()
+	r.Get("/abort", func(c *Context) {
+		panic(http.ErrAbortHandler)
+	})
+
+	defer func() {
+		if recover() != http.ErrAbortHandler {
+			t.Error("http.ErrAbortHandler should be panicked again")
+		}
+	}()
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/abort", nil))
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"fmt"
+	"log"
 	"net/http"
 	"strings"
 
@@ -24,6 +25,9 @@ type ErrorMessage struct {
 	Message string `json:"message"`
 }
 
+// sharedValue - Example typed key for sharing values between middleware
+var sharedValue = fit.NewKey[string]("shared_value")
+
 func DefaultLogger() fit.ResponseHandler {
 
 	return func(c *fit.Context) {
@@ -55,9 +59,9 @@ func DefaultLogger() fit.ResponseHandler {
 
 // User - Example endpoint function
 func User(c *fit.Context) {
-	_, apiToken := c.Shared().Get("shared_value")
+	apiToken, _ := sharedValue.Get(c)
 	_, username := c.Parameters().GetByName("username")
-	m := Message{username, apiToken.(string), fmt.Sprintf("You are allowed to view this page, because your name is '%s'.", username)}
+	m := Message{username, apiToken, fmt.Sprintf("You are allowed to view this page, because your name is '%s'.", username)}
 
 	c.JSON(m)
 }
@@ -73,7 +77,7 @@ func OnlyAllowUsersWithName(username string) fit.ResponseHandler {
 			return
 		}
 
-		c.Shared().Set("shared_value", "some shared value")
+		sharedValue.Set(c, "some shared value")
 
 		c.Next()
 	}
@@ -110,5 +114,5 @@ func main() {
 		c.JSON(broken)
 	})
 
-	router.Serve(4000)
+	log.Fatal(router.Serve(4000))
 }
@@ -0,0 +1,149 @@
+package fit
+
+import (
+	"strings"
+	"time"
+)
+
+// Group is a set of routes sharing a path prefix and middleware.
+// All routes are inserted into the tree of the router the group was created from
+type Group struct {
+	// Router the routes are inserted into
+	router *Router
+
+	// Group the group was created from, if nested
+	parent *Group
+
+	// Path prefix prepended to every route of the group
+	prefix string
+
+	// Contains ResponseHandler(s) called before the handlers assigned on the routes of the group
+	before []ResponseHandler
+
+	// Contains ResponseHandler(s) called after the handlers assigned on the routes of the group
+	after []ResponseHandler
+
+	// Timeout of the routes in the group, see Options.Timeout
+	timeout time.Duration
+}
+
+// Group creates a new group of routes with the given prefix.
+// The supplied handler(s) are called before the handlers of every route in the group
+func (r *Router) Group(prefix string, handlers ...ResponseHandler) *Group {
+	return &Group{r, nil, prefix, handlers, nil, 0}
+}
+
+// Group creates a nested group, inheriting the prefix and middleware of the group
+func (g *Group) Group(prefix string, handlers ...ResponseHandler) *Group {
+	return &Group{g.router, g, g.prefix + prefix, handlers, nil, 0}
+}
+
+// Before appends handler(s) before the handlers of the routes in the group, including the ones already added
+func (g *Group) Before(handlers ...ResponseHandler) {
+	g.before = append(g.before, handlers...)
+	g.router.res.walk(g.router.buildChains)
+}
+
+// After appends handler(s) after the handlers of the routes in the group, including the ones already added
+func (g *Group) After(handlers ...ResponseHandler) {
+	g.after = append(g.after, handlers...)
+	g.router.res.walk(g.router.buildChains)
+}
+
+// Timeout sets the timeout of the routes added to the group afterwards, unless set on the route itself
+func (g *Group) Timeout(timeout time.Duration) *Group {
+	g.timeout = timeout
+	return g
+}
+
+// routeTimeout returns the timeout of the group, or the closest parent with a timeout
+func (g *Group) routeTimeout() time.Duration {
+	if g.timeout == 0 && g.parent != nil {
+		return g.parent.routeTimeout()
+	}
+
+	return g.timeout
+}
+
+// beforeChain returns the before handlers, starting with the outermost group
+func (g *Group) beforeChain() []ResponseHandler {
+	if g.parent == nil {
+		return append([]ResponseHandler{}, g.before...)
+	}
+
+	return append(g.parent.beforeChain(), g.before...)
+}
+
+// afterChain returns the after handlers, ending with the outermost group
+func (g *Group) afterChain() []ResponseHandler {
+	if g.parent == nil {
+		return g.after
+	}
+
+	return append(append([]ResponseHandler{}, g.after...), g.parent.afterChain()...)
+}
+
+// addRoute adds the route to the router. The middleware of the group is resolved when the chains are built
+func (g *Group) addRoute(path string, methods []string, handlers ...ResponseHandler) *Options {
+	return g.router.insertRoute(g, g.prefix+path, methods, handlers...).Timeout(g.routeTimeout())
+}
+
+// Get - helper method for adding routes to the group accessible via get method
+func (g *Group) Get(path string, handlers ...ResponseHandler) *Options {
+	return g.addRoute(path, []string{"GET"}, handlers...)
+}
+
+// Post - helper method for adding routes to the group accessible via post method
+func (g *Group) Post(path string, handlers ...ResponseHandler) *Options {
+	return g.addRoute(path, []string{"POST"}, handlers...)
+}
+
+// Put - helper method for adding routes to the group accessible via put method
+func (g *Group) Put(path string, handlers ...ResponseHandler) *Options {
+	return g.addRoute(path, []string{"PUT"}, handlers...)
+}
+
+// Patch - helper method for adding routes to the group accessible via patch method
+func (g *Group) Patch(path string, handlers ...ResponseHandler) *Options {
+	return g.addRoute(path, []string{"PATCH"}, handlers...)
+}
+
+// Delete - helper method for adding routes to the group accessible via delete method
+func (g *Group) Delete(path string, handlers ...ResponseHandler) *Options {
+	return g.addRoute(path, []string{"DELETE"}, handlers...)
+}
+
+// Head - helper method for adding routes to the group accessible via head method
+func (g *Group) Head(path string, handlers ...ResponseHandler) *Options {
+	return g.addRoute(path, []string{"HEAD"}, handlers...)
+}
+
+// Options - helper method for adding routes to the group accessible via options method
+func (g *Group) Options(path string, handlers ...ResponseHandler) *Options {
+	return g.addRoute(path, []string{"OPTIONS"}, handlers...)
+}
+
+// Connect - helper method for adding routes to the group accessible via connect method
+func (g *Group) Connect(path string, handlers ...ResponseHandler) *Options {
+	return g.addRoute(path, []string{"CONNECT"}, handlers...)
+}
+
+// Trace - helper method for adding routes to the group accessible via trace method
+func (g *Group) Trace(path string, handlers ...ResponseHandler) *Options {
+	return g.addRoute(path, []string{"TRACE"}, handlers...)
+}
+
+// Any - helper method for adding routes to the group accessible via all the standard methods
+func (g *Group) Any(path string, handlers ...ResponseHandler) *Options {
+	return g.addRoute(path, anyMethods, handlers...)
+}
+
+// Match - helper method for adding routes to the group accessible via the given methods
+func (g *Group) Match(methods []string, path string, handlers ...ResponseHandler) *Options {
+	upper := make([]string, len(methods))
+	for i, method := range methods {
+		upper[i] = strings.ToUpper(method)
+	}
+
+	return g.addRoute(path, upper, handlers...)
+}
@@ -0,0 +1,68 @@
+package fit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func recordingHandler(name string) ResponseHandler {
+	return func(c *Context) {
+		_, value := c.Shared().Get("calls")
+		calls, _ := value.([]string)
+		c.Shared().Set("calls", append(calls, name))
+		c.Next()
+	}
+}
+
+func TestGroupPrefixAndMiddleware(t *testing.T) {
+	r := NewRouter()
+	var calls []string
+
+	api := r.Group("/api", recordingHandler("api.before"))
+	api.After(recordingHandler("api.after"))
+
+	v1 := api.Group("/v1", recordingHandler("v1.before"))
+	v1.After(recordingHandler("v1.after"))
+
+	v1.Get("/users/:id", recordingHandler("handler"))
+	r.After(func(c *Context) {
+		_, value := c.Shared().Get("calls")
+		calls = value.([]string)
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/users/2", nil))
+
+	expected := []string{"api.before", "v1.before", "handler", "v1.after", "api.after"}
+	if !reflect.DeepEqual(calls, expected) {
+		t.Errorf("Group middleware was called in the wrong order. Expected %s, got %s", expected, calls)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/users/2", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Route should only exist with the group prefix. Expected %d, got %d", http.StatusNotFound, w.Code)
+	}
+}
+
+func TestGroupMiddlewareAddedAfterRoutes(t *testing.T) {
+	r := NewRouter()
+
+	admin := r.Group("/admin")
+	admin.Get("/users", func(c *Context) {
+		c.String("users")
+	})
+
+	admin.Before(func(c *Context) {
+		c.AbortWithStatus(http.StatusUnauthorized)
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/admin/users", nil))
+
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Group middleware should apply to existing routes. Expected %d, got %d", http.StatusUnauthorized, w.Code)
+	}
+}
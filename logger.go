@@ -0,0 +1,71 @@
+package fit
+
+import (
+	"fmt"
+	"log"
+	"strings"
+)
+
+// Logger is used for the internal diagnostics of the router, e.g. ignored constraints, recovered panics
+// and the address being served on. The arguments are alternating keys and values, like log/slog,
+// making *slog.Logger satisfy the interface. Set it using Router.Log. The diagnostics are discarded pr. default,
+// except recovered panics, which are written to the standard logger of the log package
+type Logger interface {
+	Debug(msg string, args ...interface{})
+	Info(msg string, args ...interface{})
+	Warn(msg string, args ...interface{})
+	Error(msg string, args ...interface{})
+}
+
+// discardLogger discards everything, keeping the library silent unless a Logger is set.
+// Recovered panics are the exception, see panicLog
+type discardLogger struct{}
+
+func (discardLogger) Debug(string, ...interface{}) {}
+func (discardLogger) Info(string, ...interface{})  {}
+func (discardLogger) Warn(string, ...interface{})  {}
+func (discardLogger) Error(string, ...interface{}) {}
+
+// log returns the Logger of the router, or one discarding everything
+func (r *Router) log() Logger {
+	if r == nil || r.Log == nil {
+		return discardLogger{}
+	}
+
+	return r.Log
+}
+
+// stderrLogger writes to the standard logger of the log package, like net/http reports panics.
+// Used for recovered panics when no Logger is set, as they must be reported regardless
+type stderrLogger struct{}
+
+func (stderrLogger) Debug(msg string, args ...interface{}) { stderrLog("DEBUG", msg, args) }
+func (stderrLogger) Info(msg string, args ...interface{})  { stderrLog("INFO", msg, args) }
+func (stderrLogger) Warn(msg string, args ...interface{})  { stderrLog("WARN", msg, args) }
+func (stderrLogger) Error(msg string, args ...interface{}) { stderrLog("ERROR", msg, args) }
+
+// stderrLog writes the message with the fields as key=value pairs. Multi-line values, e.g. stack traces, go last
+func stderrLog(level, msg string, args []interface{}) {
+	var b, multiline strings.Builder
+	fmt.Fprintf(&b, "fit: %s %s", level, msg)
+
+	for i := 0; i+1 < len(args); i += 2 {
+		value := fmt.Sprint(args[i+1])
+		if strings.Contains(value, "\n") {
+			fmt.Fprintf(&multiline, "\n%v:\n%s", args[i], value)
+			continue
+		}
+		fmt.Fprintf(&b, " %v=%q", args[i], value)
+	}
+
+	log.Print(b.String() + multiline.String())
+}
+
+// panicLog returns the Logger of the router, or one writing to stderr, used for reporting recovered panics
+func (r *Router) panicLog() Logger {
+	if r == nil || r.Log == nil {
+		return stderrLogger{}
+	}
+
+	return r.Log
+}
@@ -0,0 +1,148 @@
+package fit
+
+import (
+	"bytes"
+	"log"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// *slog.Logger should be usable as the Logger of the router
+var _ Logger = (*slog.Logger)(nil)
+
+type logRecord struct {
+	level  string
+	msg    string
+	fields map[string]interface{}
+}
+
+type recordingLogger struct {
+	mu      sync.Mutex
+	records []logRecord
+}
+
+func (l *recordingLogger) record(level, msg string, args []interface{}) {
+	fields := map[string]interface{}{}
+	for i := 0; i+1 < len(args); i += 2 {
+		fields[args[i].(string)] = args[i+1]
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.records = append(l.records, logRecord{level, msg, fields})
+}
+
+// waitFor waits for the amount of records, as they might be logged from another goroutine
+func (l *recordingLogger) waitFor(amount int) []logRecord {
+	for deadline := time.Now().Add(time.Second); ; time.Sleep(time.Millisecond) {
+		l.mu.Lock()
+		records := l.records
+		l.mu.Unlock()
+
+		if len(records) >= amount || time.Now().After(deadline) {
+			return records
+		}
+	}
+}
+
+func (l *recordingLogger) Debug(msg string, args ...interface{}) { l.record("debug", msg, args) }
+func (l *recordingLogger) Info(msg string, args ...interface{})  { l.record("info", msg, args) }
+func (l *recordingLogger) Warn(msg string, args ...interface{})  { l.record("warn", msg, args) }
+func (l *recordingLogger) Error(msg string, args ...interface{}) { l.record("error", msg, args) }
+
+func TestLoggerConstraintWarnings(t *testing.T) {
+	r := NewRouter()
+	logger := &recordingLogger{}
+	r.Log = logger
+
+	r.Get("/user/:id", nil).Where("id", "", "name", "^[a-z]+$")
+
+	if len(logger.records) != 2 {
+		t.Fatalf("Expected 2 warnings, got %v", logger.records)
+	}
+
+	for i, constraint := range []string{"id", "name"} {
+		record := logger.records[i]
+		if record.level != "warn" || record.fields["path"] != "/user/:id" || record.fields["constraint"] != constraint {
+			t.Errorf("Warning for constraint '%s' is wrong, got %v", constraint, record)
+		}
+	}
+}
+
+func TestLoggerPrintTree(t *testing.T) {
+	r := NewRouter()
+	logger := &recordingLogger{}
+	r.Log = logger
+
+	r.Get("/users", nil)
+	r.Match([]string{"GET", "POST"}, "/users/:id", nil)
+	r.PrintTree()
+
+	if len(logger.records) != 2 {
+		t.Fatalf("Expected a debug record for every route, got %v", logger.records)
+	}
+
+	for _, record := range logger.records {
+		if record.level != "debug" || record.fields["path"] == nil || record.fields["methods"] == nil {
+			t.Errorf("Route record is wrong, got %v", record)
+		}
+	}
+
+	var tree bytes.Buffer
+	r.WriteTree(&tree)
+
+	if !strings.Contains(tree.String(), "users") {
+		t.Errorf("Tree should contain the routes, got '%s'", tree.String())
+	}
+}
+
+func TestLoggerSlog(t *testing.T) {
+	var out bytes.Buffer
+	r := NewRouter()
+	r.Log = slog.New(slog.NewTextHandler(&out, nil))
+
+	r.Get("/user/:id", nil).Where("missing", "^[0-9]+$")
+
+	if line := out.String(); !strings.Contains(line, "level=WARN") || !strings.Contains(line, "constraint=missing") {
+		t.Errorf("Warning was not written through slog, got '%s'", line)
+	}
+}
+
+func TestLoggerDefaultSilent(t *testing.T) {
+	r := NewRouter()
+
+	if _, ok := r.log().(discardLogger); !ok {
+		t.Error("Diagnostics should be discarded by default")
+	}
+
+	r.Get("/user/:id", nil).Where("missing", "^[0-9]+$")
+	r.PrintTree()
+}
+
+func TestLoggerDefaultReportsPanics(t *testing.T) {
+	var out bytes.Buffer
+	log.SetOutput(&out)
+	defer log.SetOutput(os.Stderr)
+
+	r := NewRouter()
+	r.Get("/panic", func(c *Context) {
+		panic("something went wrong")
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/panic", nil))
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("Status is wrong. Expected %d, got %d", http.StatusInternalServerError, w.Code)
+	}
+
+	if output := out.String(); !strings.Contains(output, `panic="something went wrong"`) || !strings.Contains(output, "TestLoggerDefaultReportsPanics") {
+		t.Errorf("Recovered panic should be reported with the stack trace when no Logger is set, got '%s'", output)
+	}
+}
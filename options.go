@@ -2,20 +2,49 @@ package fit
 
 import (
 	"fmt"
+	"regexp"
 	"strings"
+	"time"
 )
 
 type Options struct {
-	regex map[string]string
-	name  string
-	path  string
+	regex   map[string]*regexp.Regexp
+	name    string
+	path    string
+	timeout time.Duration
+	router  *Router
+
+	// Group the route was added to, if any
+	group *Group
+}
+
+// Timeout sets the maximum duration of the handlers of the route. They run in a goroutine with their response buffered.
+// When exceeded, the context of the request is cancelled, the buffered response is discarded
+// and the error handler answers with http.StatusGatewayTimeout, even though the handlers are still running.
+// Flush and Hijack are not supported by the buffered response
+func (r *Options) Timeout(timeout time.Duration) *Options {
+	r.timeout = timeout
+	return r
+}
+
+// Name names the route, making it possible to generate URLs for it using Router.URL
+func (r *Options) Name(name string) *Options {
+	if _, ok := r.router.names[name]; ok {
+		panic(fmt.Sprintf("Route named '%s' already exists", name))
+	}
+
+	r.name = name
+	r.router.names[name] = r
+
+	return r
 }
 
-// Where ...
+// Where adds regular expression constraints to the parameters of the route, given as pairs of name and pattern.
+// The patterns are compiled once, and an invalid pattern will panic on registration
 func (r *Options) Where(constraints ...string) *Options {
 	regex, constraintLength := r.regex, len(constraints)
 	if regex == nil {
-		regex = make(map[string]string)
+		regex = make(map[string]*regexp.Regexp)
 	}
 
 	if constraintLength%2 != 0 {
@@ -27,16 +56,21 @@ func (r *Options) Where(constraints ...string) *Options {
 
 		// Empty constraint(s)
 		if constraintValue == "" || constraintName == "" {
-			fmt.Println("Empty constraint was supplied. Ignoring")
+			r.router.log().Warn("Empty constraint was supplied. Ignoring", "path", r.path, "constraint", constraintName)
 			continue
 		}
 
 		// If constraint does not exist in url path, we will ignore it
 		if !strings.Contains(r.path, constraintName) {
-			fmt.Printf("Constraint '%s' does not exist in path '%s'. Ignoring.\n", constraintName, r.path)
+			r.router.log().Warn("Constraint does not exist in path. Ignoring", "path", r.path, "constraint", constraintName)
 			continue
 		}
-		regex[constraintName] = constraintValue
+
+		compiled, err := regexp.Compile(constraintValue)
+		if err != nil {
+			panic(fmt.Sprintf("Constraint '%s' for path '%s' is invalid: %s", constraintName, r.path, err))
+		}
+		regex[constraintName] = compiled
 	}
 
 	r.regex = regex
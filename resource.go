@@ -1,12 +1,26 @@
 package fit
 
+import "sort"
+
 type resource struct {
-	path     string
-	methods  map[string][]ResponseHandler
+	// Static path of the resource, or the name of the parameter for parameter and catch-all resources
+	path    string
+	methods map[string][]ResponseHandler
+
+	// Handlers of each method, merged with the global before and after handlers of the router
+	chains   map[string][]ResponseHandler
 	prefix   string
 	children []*resource
-	options  *Options
-	max      int
+
+	// Parameter children, tried in order of insertion
+	parameters []*resource
+
+	// Catch-all child, if any
+	catchAll *resource
+
+	// Options of each method, holding the constraints and timeout of the route
+	options map[string]*Options
+	max     int
 }
 
 // Helper functions
@@ -14,9 +28,10 @@ func newResource() *resource {
 	return &resource{
 		path:     "",
 		methods:  make(map[string][]ResponseHandler),
+		chains:   make(map[string][]ResponseHandler),
 		children: make([]*resource, 0),
 		prefix:   "",
-		options:  &Options{},
+		options:  make(map[string]*Options),
 	}
 }
 
@@ -38,8 +53,46 @@ func (res *resource) addMethods(methods []string, options *Options, handlers ...
 			panic("handler existed!")
 		}
 		res.methods[m] = handlers
-		res.options = options
+		res.options[m] = options
+	}
+}
+
+// allowedMethods returns the sorted methods registered on the resource
+func (res *resource) allowedMethods() []string {
+	methods := make([]string, 0, len(res.methods))
+	for method := range res.methods {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+
+	return methods
+}
+
+// satisfies checks the parameters against the constraints of the route registered for the method.
+// An empty method is satisfied by any of the routes of the resource
+func (res *resource) satisfies(method string, parameters Parameters) bool {
+	if method == "" {
+		for m := range res.options {
+			if res.satisfies(m, parameters) {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	options, ok := res.options[method]
+	if !ok {
+		return false
 	}
+
+	for name, constraint := range options.regex {
+		if ok, param := parameters.GetByName(name); ok && !constraint.MatchString(param) {
+			return false
+		}
+	}
+
+	return true
 }
 
 func (res *resource) getIndexPosition(target byte) int {
@@ -72,3 +125,83 @@ func (res *resource) getChild(index byte) *resource {
 	}
 	return res.children[i]
 }
+
+// walk calls the function for the resource and all resources below it
+func (res *resource) walk(fn func(*resource)) {
+	fn(res)
+
+	for _, child := range res.children {
+		child.walk(fn)
+	}
+	for _, child := range res.parameters {
+		child.walk(fn)
+	}
+	if res.catchAll != nil {
+		res.catchAll.walk(fn)
+	}
+}
+
+// insertStatic inserts the static path below the resource, splitting existing children when
+// they only share a part of the path. Returns the resource for the end of the path
+func (res *resource) insertStatic(path string) *resource {
+	for len(path) > 0 {
+		child := res.getChild(path[0])
+		if child == nil {
+			return res.insertChild(path[0], newResourceFromPath(path))
+		}
+
+		i, childPathLength := 0, len(child.path)
+		for i < childPathLength && i < len(path) && path[i] == child.path[i] {
+			i++
+		}
+
+		if i < childPathLength {
+			child.split(i)
+		}
+
+		res, path = child, path[i:]
+	}
+
+	return res
+}
+
+// split splits the resource at the given position, moving everything after it into a new child
+func (res *resource) split(position int) {
+	child := res.copy()
+	child.path = res.path[position:]
+
+	res.path = res.path[:position]
+	res.methods = make(map[string][]ResponseHandler)
+	res.chains = make(map[string][]ResponseHandler)
+	res.prefix = string(child.path[0])
+	res.children = []*resource{child}
+	res.parameters = nil
+	res.catchAll = nil
+	res.options = make(map[string]*Options)
+}
+
+// insertParameter returns the parameter child with the given name, inserting it if it doesn't exist
+func (res *resource) insertParameter(name string) *resource {
+	for _, child := range res.parameters {
+		if child.path == name {
+			return child
+		}
+	}
+
+	child := newResourceFromPath(name)
+	res.parameters = append(res.parameters, child)
+
+	return child
+}
+
+// insertCatchAll returns the catch-all child, inserting it if it doesn't exist.
+// Only one catch-all can exist pr. resource, so a different name will panic
+func (res *resource) insertCatchAll(name string) *resource {
+	if res.catchAll == nil {
+		res.catchAll = newResourceFromPath(name)
+	} else if res.catchAll.path != name {
+		panic("catch-all '" + name + "' conflicts with existing catch-all '" + res.catchAll.path + "'")
+	}
+
+	return res.catchAll
+}
@@ -0,0 +1,223 @@
+package fit
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+)
+
+// responseWriter wraps the http.ResponseWriter, keeping track of the status, the amount of bytes written
+// and whether the header was written. The status is tracked on the Context, making it visible to the logger,
+// even though handlers write directly to Context.Writer()
+type responseWriter struct {
+	http.ResponseWriter
+
+	// Context receiving the status
+	c *Context
+
+	// Amount of bytes written to the body
+	size int
+
+	// Whether the header has been written
+	wroteHeader bool
+
+	// Whether the written response is sealed, discarding further writes. Set before calling the after handlers
+	sealed bool
+}
+
+// WriteHeader writes the header once, further calls are ignored to avoid superfluous WriteHeader calls
+func (w *responseWriter) WriteHeader(code int) {
+	if w.wroteHeader {
+		return
+	}
+
+	w.wroteHeader = true
+	w.c.status = code
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	if w.sealed {
+		return 0, ErrResponseWritten
+	}
+
+	n, err := w.ResponseWriter.Write(b)
+	w.size += n
+
+	return n, err
+}
+
+// ReadFrom uses the io.ReaderFrom of the underlying writer if supported, e.g. for sendfile
+func (w *responseWriter) ReadFrom(reader io.Reader) (int64, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	if w.sealed {
+		return 0, ErrResponseWritten
+	}
+
+	var n int64
+	var err error
+	if readerFrom, ok := w.ResponseWriter.(io.ReaderFrom); ok {
+		n, err = readerFrom.ReadFrom(reader)
+	} else {
+		n, err = io.Copy(struct{ io.Writer }{w.ResponseWriter}, reader)
+	}
+	w.size += int(n)
+
+	return n, err
+}
+
+// Flush flushes the underlying writer if it supports http.Flusher
+func (w *responseWriter) Flush() {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack hijacks the connection if the underlying writer supports http.Hijacker
+func (w *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("fit: the response writer does not support hijacking")
+	}
+
+	conn, buffer, err := hijacker.Hijack()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// The connection is taken over, so the header is considered written
+	w.wroteHeader = true
+
+	return conn, buffer, nil
+}
+
+// Push initiates a HTTP/2 server push if the underlying writer supports http.Pusher
+func (w *responseWriter) Push(target string, opts *http.PushOptions) error {
+	if pusher, ok := w.ResponseWriter.(http.Pusher); ok {
+		return pusher.Push(target, opts)
+	}
+
+	return http.ErrNotSupported
+}
+
+// Unwrap returns the underlying writer, used by http.ResponseController
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// timeoutWriter buffers the response of handlers running with a timeout,
+// as it can only be written when they finished in time. Writes after the timeout fail with http.ErrHandlerTimeout
+type timeoutWriter struct {
+	mu       sync.Mutex
+	header   http.Header
+	code     int
+	body     bytes.Buffer
+	timedOut bool
+	finished bool
+}
+
+func (w *timeoutWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *timeoutWriter) WriteHeader(code int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.timedOut || w.code != 0 {
+		return
+	}
+
+	w.code = code
+}
+
+func (w *timeoutWriter) Write(b []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+
+	if w.code == 0 {
+		w.code = http.StatusOK
+	}
+
+	return w.body.Write(b)
+}
+
+// timeout discards the buffered response, making further writes fail.
+// Returns false if the handlers finished first, keeping the response
+func (w *timeoutWriter) timeout() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.finished {
+		return false
+	}
+
+	w.timedOut = true
+	w.body.Reset()
+
+	return true
+}
+
+// finish marks the handlers as finished. Returns false if the timeout was exceeded first
+func (w *timeoutWriter) finish() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.timedOut {
+		return false
+	}
+
+	w.finished = true
+
+	return true
+}
+
+// writeTo writes the buffered response to the writer, if anything was written
+func (w *timeoutWriter) writeTo(writer http.ResponseWriter) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	header := writer.Header()
+	for key, values := range w.header {
+		header[key] = values
+	}
+
+	if w.code != 0 {
+		writer.WriteHeader(w.code)
+		writer.Write(w.body.Bytes())
+	}
+}
+
+// detachedWriter is the writer of a copied Context, which can't write the response
+type detachedWriter struct {
+	header http.Header
+}
+
+func (w *detachedWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *detachedWriter) WriteHeader(int) {}
+
+func (w *detachedWriter) Write([]byte) (int, error) {
+	return 0, ErrResponseWritten
+}
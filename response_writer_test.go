@@ -0,0 +1,176 @@
+package fit
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestResponseWriterTracking(t *testing.T) {
+	r := NewRouter()
+	r.Get("/direct", func(c *Context) {
+		c.Writer().WriteHeader(http.StatusCreated)
+		c.Writer().Write([]byte("hello"))
+	})
+	r.Get("/implicit", func(c *Context) {
+		io.Copy(c.Writer(), strings.NewReader("hello world"))
+	})
+
+	var status, size int
+	r.Logger(func(c *Context) {
+		status, size = c.Status(), c.Size()
+	})
+
+	tests := []struct {
+		path   string
+		status int
+		size   int
+	}{
+		{"/direct", http.StatusCreated, 5},
+		{"/implicit", http.StatusOK, 11},
+	}
+
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", test.path, nil))
+
+		if status != test.status || w.Code != test.status {
+			t.Errorf("Status for '%s' is wrong. Expected %d, got %d (logged %d)", test.path, test.status, w.Code, status)
+		}
+
+		if size != test.size || w.Body.Len() != test.size {
+			t.Errorf("Size for '%s' is wrong. Expected %d, got %d (logged %d)", test.path, test.size, w.Body.Len(), size)
+		}
+	}
+}
+
+type failingHijacker struct {
+	http.ResponseWriter
+}
+
+func (failingHijacker) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return nil, nil, http.ErrNotSupported
+}
+
+func TestResponseWriterInterfaces(t *testing.T) {
+	c := newContext()
+	recorder := httptest.NewRecorder()
+	w := &responseWriter{ResponseWriter: recorder, c: c}
+
+	var writer http.ResponseWriter = w
+	flusher, ok := writer.(http.Flusher)
+	if !ok {
+		t.Fatal("Response writer should implement http.Flusher")
+	}
+
+	flusher.Flush()
+	if !recorder.Flushed || c.Status() != http.StatusOK {
+		t.Error("Flush should flush the underlying writer and write the header")
+	}
+
+	// httptest.ResponseRecorder supports neither hijacking nor pushing
+	if _, _, err := writer.(http.Hijacker).Hijack(); err == nil {
+		t.Error("Hijack should fail when the underlying writer doesn't support it")
+	}
+
+	// A failed hijack must not count as written
+	failing := &responseWriter{ResponseWriter: failingHijacker{httptest.NewRecorder()}, c: newContext()}
+	if _, _, err := failing.Hijack(); err == nil || failing.wroteHeader {
+		t.Errorf("Failed hijack should return the error and leave the response unwritten, got %v", err)
+	}
+
+	if err := writer.(http.Pusher).Push("/style.css", nil); err != http.ErrNotSupported {
+		t.Errorf("Push should return http.ErrNotSupported, got %v", err)
+	}
+
+	if controller := http.NewResponseController(writer); controller.Flush() != nil {
+		t.Error("http.ResponseController should be able to unwrap the writer")
+	}
+}
+
+func TestDoubleWrites(t *testing.T) {
+	r := NewRouter()
+	r.Get("/twice", func(c *Context) {
+		c.JSON("first", http.StatusCreated)
+		c.JSON("second", http.StatusBadRequest)
+		c.String("third")
+		c.Next()
+	})
+
+	var written bool
+	var status int
+	r.After(func(c *Context) {
+		written, status = c.Written(), c.Status()
+		c.Writer().Write([]byte("clobbered"))
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/twice", nil))
+
+	if w.Code != http.StatusCreated || w.Body.String() != "\"first\"\n" {
+		t.Errorf("Only the first write should be used, got %d '%s'", w.Code, w.Body.String())
+	}
+
+	if !written || status != http.StatusCreated {
+		t.Errorf("After handler should see the written response, got written %t with status %d", written, status)
+	}
+
+	r.WritePolicy = PanicOnWrite
+
+	var recovered interface{}
+	r.PanicHandler = func(c *Context, value interface{}) {
+		recovered = value
+	}
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/twice", nil))
+
+	if recovered != ErrResponseWritten {
+		t.Errorf("Expected a panic with ErrResponseWritten, got %v", recovered)
+	}
+
+	// The default panic handler must not panic again by rendering the written response
+	r.PanicHandler = panicHandler()
+
+	var logged bool
+	r.Logger(func(c *Context) {
+		logged = true
+	})
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/twice", nil))
+
+	if !logged || w.Code != http.StatusCreated || w.Body.String() != "\"first\"\n" {
+		t.Errorf("Logger should run with the first response kept, got logged %t with %d '%s'", logged, w.Code, w.Body.String())
+	}
+
+	// Neither must a custom panic handler panicking
+	logged = false
+	r.PanicHandler = func(c *Context, value interface{}) {
+		c.JSON("recovered")
+	}
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/twice", nil))
+
+	if !logged {
+		t.Error("A panic in the panic handler should not escape ServeHTTP")
+	}
+}
+
+func TestStreamingWritesAreAllowed(t *testing.T) {
+	r := NewRouter()
+	r.Get("/stream", func(c *Context) {
+		c.Writer().Write([]byte("a"))
+		c.Writer().Write([]byte("b"))
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/stream", nil))
+
+	if w.Body.String() != "ab" {
+		t.Errorf("Multiple writes to the writer should be allowed, got '%s'", w.Body.String())
+	}
+}
@@ -1,10 +1,16 @@
 package fit
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"log"
+	"net"
 	"net/http"
-	"regexp"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 )
 
 const (
@@ -27,34 +33,147 @@ type Router struct {
 	// Contains a ResponseHandler called after everything, not dependent of the middleware chain
 	logger ResponseHandler
 
+	// Logger for the internal diagnostics of the router, compatible with *slog.Logger.
+	// Silent when nil, except recovered panics, which are written to the log package
+	Log Logger
+
 	// Contains the default function to use when a page was not found (404)
 	NotFound ResponseHandler
 
+	// Contains the default function to use when the page exists, but not for the requested method (405)
+	MethodNotAllowed ResponseHandler
+
+	// Contains the default function to use when no renderer is acceptable in Context.Negotiate (406)
+	NotAcceptable ResponseHandler
+
 	// A boolean value for toggling automatic redirects, if the route exists with (or without) slashes "/"
 	RedirectSlashes bool
+
+	// A boolean value for toggling automatic replies to OPTIONS requests, with the Allow header set
+	HandleOPTIONS bool
+
+	// A boolean value for toggling automatic handling of HEAD requests, using the GET handlers.
+	// The body is written through, letting net/http discard it and set the Content-Length
+	HandleHEAD bool
+
+	// Contains the function to call on automatic OPTIONS replies, e.g. for CORS preflight requests.
+	// The Allow header is set before it's called
+	GlobalOPTIONS ResponseHandler
+
+	// Validator used by Context.Validate and Context.MustBind
+	Validator Validator
+
+	// Contains the function to call when a response can't be rendered, e.g. when encoding fails
+	ErrorHandler func(c *Context, err error)
+
+	// Contains the function to call when a handler panics. The stack trace is available using debug.Stack(),
+	// except for routes with a timeout, where the recovered value is a *PanicError holding the stack trace.
+	// When nil, panics are not recovered
+	PanicHandler func(c *Context, recovered interface{})
+
+	// Decides what happens when a response helper is called after the response has been written.
+	// Discarded pr. default
+	WritePolicy WritePolicy
+
+	// Indentation used by Context.JSON, e.g. "  " for pretty-printing. The output is compact when empty
+	JSONIndent string
+
+	// A boolean value for toggling escaping of HTML characters (<, >, &) in the output of Context.JSON
+	JSONEscapeHTML bool
+
+	// Contains the renderers used by Context.Negotiate, the first one being the default
+	renderers []renderer
+
+	// Contains the routes named using Options.Name, used for generating URLs
+	names map[string]*Options
+
+	// Maximum duration of draining in-flight requests, when the context of Run or RunListener is cancelled.
+	// When exceeded, the remaining connections are closed. Zero means no limit
+	ShutdownTimeout time.Duration
+
+	// Pool of Context(s), reused between requests to avoid allocations
+	pool sync.Pool
+
+	// Guards the server while it's being started or shut down
+	mu sync.Mutex
+
+	// The running server, if any. Set by RunListener and used by Shutdown
+	server *http.Server
+
+	// Whether Shutdown was called before the server was running, making the next RunListener return at once
+	shutdown bool
 }
 
 // NewRouter returns a new instance of the Router struct.
 // It's created with an empty resource and a standard not found handler for 404 requests.
 func NewRouter() *Router {
-	return &Router{
-		newResource(),     // Resource creation
-		nil,               // Before ResponseHandler(s)
-		nil,               // After ResponseHandler(s)
-		nil,               // Logger ResponseHandler
-		notFoundHandler(), // Default not found handler
-		true,              // RedirectSlashes is activated pr. default
+	r := &Router{
+		newResource(),             // Resource creation
+		nil,                       // Before ResponseHandler(s)
+		nil,                       // After ResponseHandler(s)
+		nil,                       // Logger ResponseHandler
+		nil,                       // Diagnostics are discarded pr. default
+		notFoundHandler(),         // Default not found handler
+		methodNotAllowedHandler(), // Default method not allowed handler
+		notAcceptableHandler(),    // Default not acceptable handler
+		true,                      // RedirectSlashes is activated pr. default
+		false,                     // HandleOPTIONS is opt-in
+		false,                     // HandleHEAD is opt-in
+		nil,                       // GlobalOPTIONS handler
+		NewTagValidator(),         // Default struct tag validator
+		errorHandler(),            // Default error handler
+		panicHandler(),            // Default panic handler, logging the stack trace
+		DiscardWrite,              // Writes after the response has been written are discarded
+		"",                        // JSON is compact pr. default
+		true,                      // JSON escapes HTML pr. default, like json.Marshal
+		defaultRenderers(),        // Renderers for content negotiation
+		make(map[string]*Options), // Named routes
+		30 * time.Second,          // Draining is limited to 30 seconds pr. default
+		sync.Pool{},               // Context pool
+		sync.Mutex{},              // Server mutex
+		nil,                       // Server is created when running
+		false,                     // Shutdown has not been requested
 	}
+
+	r.pool.New = func() interface{} {
+		return newContext()
+	}
+
+	return r
 }
 
 // Before appends handler(s) before all other handlers, globally for the instance of the router
 func (r *Router) Before(handlers ...ResponseHandler) {
 	r.before = append(r.before, handlers...)
+	r.res.walk(r.buildChains)
 }
 
-// After appends handler(s) after all other handlers, globally for the instance of the router
+// After appends handler(s) after all other handlers, globally for the instance of the router.
+// Each of them is always called, even though a handler didn't call Next, aborted the chain or panicked.
+// Calling Next in them is not required
 func (r *Router) After(handlers ...ResponseHandler) {
 	r.after = append(r.after, handlers...)
+	r.res.walk(r.buildChains)
+}
+
+// buildChains merges the global before and after handlers, and the ones of the group, with the handlers
+// of each method of the resource. The chains are built on registration, and rebuilt when the middleware changes
+func (r *Router) buildChains(res *resource) {
+	for method, handlers := range res.methods {
+		var before, after []ResponseHandler
+		if group := res.options[method].group; group != nil {
+			before, after = group.beforeChain(), group.afterChain()
+		}
+
+		chain := make([]ResponseHandler, 0, len(r.before)+len(before)+len(handlers)+len(after)+len(r.after))
+		chain = append(chain, r.before...)
+		chain = append(chain, before...)
+		chain = append(chain, handlers...)
+		chain = append(chain, after...)
+		chain = append(chain, r.after...)
+
+		res.chains[method] = chain
+	}
 }
 
 // Logger to use. Did works the same way as the other ResponseHandlers, except it does not exist
@@ -63,32 +182,118 @@ func (r *Router) Logger(logger ResponseHandler) {
 	r.logger = logger
 }
 
-// Serve ..
-func (r *Router) Serve(port ...int) {
+// Serve serves on the given port, defaulting to 8080, until the server is shut down.
+// Returns nil if the server was closed using Shutdown, otherwise the error causing it to stop
+func (r *Router) Serve(port ...int) error {
 	// Setting the default port, as we're using variadic variables, to make it possible to use Serve() parameterless
 	portString := ":8080"
 	if len(port) > 0 {
 		portString = fmt.Sprintf(":%d", port[0])
 	}
 
-	fmt.Printf("Now serving on localhost%s\n", portString)
+	r.log().Info("Now serving", "address", "localhost"+portString)
+
+	return r.Run(context.Background(), portString)
+}
+
+// Run listens on the given address and serves until the context is cancelled.
+// On cancellation the server is shut down gracefully, letting in-flight requests finish within Router.ShutdownTimeout
+func (r *Router) Run(ctx context.Context, address string) error {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return err
+	}
+
+	return r.RunListener(ctx, listener)
+}
+
+// RunListener serves on the supplied listener until the context is cancelled, or Shutdown is called.
+// This makes it possible to bind to any address, e.g. ":0" in tests.
+// If Shutdown was called before the server was running, it returns at once
+func (r *Router) RunListener(ctx context.Context, listener net.Listener) error {
+	server := &http.Server{Addr: listener.Addr().String(), Handler: r}
+
+	r.mu.Lock()
+	if r.server != nil {
+		r.mu.Unlock()
+		listener.Close()
+		return errors.New("fit: router is already running")
+	}
+	if r.shutdown {
+		r.shutdown = false
+		r.mu.Unlock()
+		return listener.Close()
+	}
+	r.server = server
+	r.mu.Unlock()
+
+	defer func() {
+		r.mu.Lock()
+		r.server = nil
+		r.mu.Unlock()
+	}()
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- server.Serve(listener)
+	}()
+
+	select {
+	case err := <-serveErr:
+		if err == http.ErrServerClosed {
+			return nil
+		}
+		return err
+	case <-ctx.Done():
+		// The supplied context is done, so the draining can't depend on it
+		drainCtx, cancel := context.Background(), func() {}
+		if r.ShutdownTimeout > 0 {
+			drainCtx, cancel = context.WithTimeout(drainCtx, r.ShutdownTimeout)
+		}
+		defer cancel()
+
+		err := r.drain(drainCtx, server)
+		<-serveErr
+		return err
+	}
+}
+
+// Shutdown gracefully shuts down the running server, waiting for in-flight requests
+// to finish or the context to be done, closing the remaining connections when it's done.
+// If the server is not running yet, the next call to Run or RunListener returns at once
+func (r *Router) Shutdown(ctx context.Context) error {
+	r.mu.Lock()
+	server := r.server
+	if server == nil {
+		r.shutdown = true
+	}
+	r.mu.Unlock()
 
-	// Booting the server up, using a custom wrapper
-	log.Fatal(r.listenAndServe(portString))
+	if server == nil {
+		return nil
+	}
+
+	return r.drain(ctx, server)
 }
 
-// listenAndServe custom instance of the http.Server struct, to enable graceful shutdown
-// TODO: Create a listener, to enabling closing of the server again later => https://play.golang.org/p/-G7nJlH_Mz
-func (r *Router) listenAndServe(address string) error {
-	// Binding the main request function to handle all requests made
-	server := &http.Server{Addr: address, Handler: http.HandlerFunc(r.request)}
+// drain shuts down the server gracefully, and closes it when the context is done before the requests finished
+func (r *Router) drain(ctx context.Context, server *http.Server) error {
+	err := server.Shutdown(ctx)
+	if err != nil {
+		server.Close()
+	}
 
-	return server.ListenAndServe()
+	return err
 }
 
 // redirectPath fixes the path by either include a slash, or remove one.
 // Searches for the fixed path and returns a boolean value for the result, and the redirect path
 func (r *Router) redirectPath(path, method string) (bool, string) {
+	// The path of an authority-form CONNECT request is empty
+	if path == "" {
+		return false, path
+	}
+
 	redirectPath := path
 	pathLength := len(redirectPath)
 	if redirectPath[pathLength-1] == slash {
@@ -103,114 +308,268 @@ func (r *Router) redirectPath(path, method string) (bool, string) {
 	return found && handler != nil, redirectPath
 }
 
-func (r *Router) request(w http.ResponseWriter, rq *http.Request) {
-	path := rq.URL.Path
+// allowedMethods returns the methods allowed on the resource with the parameters satisfying their constraints,
+// including the ones handled automatically
+func (r *Router) allowedMethods(res *resource, parameters Parameters) []string {
+	methods := make([]string, 0, len(res.methods)+2)
+	for _, method := range res.allowedMethods() {
+		if res.satisfies(method, parameters) {
+			methods = append(methods, method)
+		}
+	}
 
-	found, handlers, parameters := r.findRoute(path, rq.Method)
-	c := newContext()
-	c.writer, c.request = w, rq
+	if res.satisfies("GET", parameters) && r.HandleHEAD {
+		if _, ok := res.methods["HEAD"]; !ok {
+			methods = append(methods, "HEAD")
+		}
+	}
+
+	if _, ok := res.methods["OPTIONS"]; !ok && r.HandleOPTIONS {
+		methods = append(methods, "OPTIONS")
+	}
 
-	if found && len(handlers) > 0 {
-		handlerChain := []ResponseHandler{}
+	sort.Strings(methods)
 
-		if r.before != nil {
-			handlerChain = append(handlerChain, r.before...)
+	return methods
+}
+
+// ServeHTTP dispatches the request to the matching route, making the Router an http.Handler.
+// This allows the router to be mounted in any http.Server, httptest.Server or another mux.
+// The Context is pooled, so neither it nor the Parameters may be used after the request has finished.
+// Use Context.Copy to pass it on, e.g. to a goroutine.
+func (r *Router) ServeHTTP(w http.ResponseWriter, rq *http.Request) {
+	c := r.pool.Get().(*Context)
+	c.reset(w, rq, r)
+
+	r.handle(c)
+
+	if r.logger != nil {
+		r.logger(c)
+	}
+
+	r.pool.Put(c)
+}
+
+// handle finds the route and calls the handler chain, or answers with redirects, 404 or 405.
+// Panics are recovered, so the logger is called regardless
+func (r *Router) handle(c *Context) {
+	defer r.recover(c)
+
+	w, rq := c.writer, c.request
+	path, method := rq.URL.Path, rq.Method
+	res := r.lookup(path, method, &c.params)
+
+	// Serving HEAD using the GET handlers, net/http discards the body
+	if res == nil && method == "HEAD" && r.HandleHEAD {
+		if res = r.lookup(path, "GET", &c.params); res != nil {
+			method = "GET"
 		}
+	}
 
-		handlerChain = append(handlerChain, handlers...)
+	// Finding the resource regardless of the method, to answer OPTIONS and 405
+	var handlers []ResponseHandler
+	if res == nil {
+		res = r.lookup(path, "", &c.params)
+	} else {
+		handlers = res.methods[method]
+	}
 
-		if r.after != nil {
-			handlerChain = append(handlerChain, r.after...)
+	if len(handlers) > 0 {
+		handlerChain := res.chains[method]
+
+		c.handlers, c.currentHandler, c.maxHandlers = handlerChain, 0, len(handlerChain)
+		c.afterIndex = len(handlerChain) - len(r.after)
+
+		if timeout := res.options[method].timeout; timeout > 0 {
+			ctx, cancel := context.WithTimeout(rq.Context(), timeout)
+			defer cancel()
+			c.request = rq.WithContext(ctx)
+
+			r.callWithTimeout(c)
+		} else {
+			c.callByIndex(0)
 		}
 
-		c.params, c.handlers, c.currentHandler, c.maxHandlers = parameters, handlerChain, 0, len(handlerChain)
+		if err := c.Err(); errors.Is(err, context.DeadlineExceeded) && !c.Written() {
+			c.Error(NewHTTPError(http.StatusGatewayTimeout).WithError(err))
+		}
 
-		c.callByIndex(0)
+		r.callAfter(c)
 	} else if found, redirectPath := r.redirectPath(path, rq.Method); found && r.RedirectSlashes {
 		c.status = http.StatusMovedPermanently
 		http.Redirect(w, rq, redirectPath, c.status)
+	} else if res != nil && rq.Method == "OPTIONS" && r.HandleOPTIONS {
+		w.Header().Set("Allow", strings.Join(r.allowedMethods(res, c.params), ", "))
+
+		if r.GlobalOPTIONS == nil {
+			c.setStatus(http.StatusNoContent)
+		} else {
+			r.GlobalOPTIONS(c)
+		}
+	} else if res != nil {
+		c.status = http.StatusMethodNotAllowed
+		w.Header().Set("Allow", strings.Join(r.allowedMethods(res, c.params), ", "))
+
+		if r.MethodNotAllowed == nil {
+			c.Error(NewHTTPError(http.StatusMethodNotAllowed))
+		} else {
+			r.MethodNotAllowed(c)
+		}
 	} else {
 		c.status = http.StatusNotFound
-		// Error handler here
 		if r.NotFound == nil {
-			fmt.Fprintln(w, "Requested page was not found")
+			c.Error(NewHTTPError(http.StatusNotFound))
 		} else {
 			r.NotFound(c)
 		}
-
 	}
+}
 
-	if r.logger != nil {
-		r.logger(c)
+// callWithTimeout calls the handlers of the route in a goroutine, on a copy of the Context writing to a buffer.
+// If they finish before the context of the request is done, the state and the buffered response are copied to the Context.
+// Otherwise the response is discarded and the handlers can't write anymore, like http.TimeoutHandler.
+// The after handlers of the router are left for the Context, and panics are passed on to it as a *PanicError
+// holding the stack of the goroutine. Panics after the timeout are logged, as nothing is waiting for the handlers
+func (r *Router) callWithTimeout(c *Context) {
+	tw := &timeoutWriter{header: make(http.Header)}
+
+	tc := newContext()
+	*tc = *c
+	tc.params = Parameters{append([]parameter(nil), c.params.stack...)}
+	tc.maxHandlers = c.afterIndex
+	tc.response = responseWriter{ResponseWriter: tw, c: tc}
+	tc.writer = &tc.response
+
+	finished := make(chan interface{}, 1)
+	go func() {
+		defer func() {
+			recovered := recover()
+			if recovered != nil && recovered != http.ErrAbortHandler {
+				recovered = &PanicError{recovered, debug.Stack()}
+			}
+
+			if tw.finish() {
+				finished <- recovered
+			} else if recovered != nil {
+				r.panicLog().Error("Panic recovered after the timeout", "panic", recovered, "method", tc.request.Method, "path", tc.request.URL.Path)
+			}
+		}()
+
+		tc.callByIndex(0)
+	}()
+
+	var recovered interface{}
+	select {
+	case recovered = <-finished:
+	case <-c.Done():
+		if tw.timeout() {
+			return
+		}
+
+		// The handlers finished meanwhile
+		recovered = <-finished
 	}
 
-}
+	c.request, c.shared, c.aborted, c.currentHandler = tc.request, tc.shared, tc.aborted, tc.currentHandler
+	tw.writeTo(c.writer)
 
-func (r *Router) addRoute(path string, methods []string, handlers ...ResponseHandler) *Options {
-	i, pathLength, res, options, max := 0, len(path), r.res, &Options{path: path}, 0
+	if recovered != nil {
+		panic(recovered)
+	}
+}
 
-	for i < pathLength {
-		position := res.getIndexPosition(path[i])
-		if position == len(res.prefix) || res.prefix[position] != path[i] {
-			position = find(path, colon, i, pathLength)
-			if position == pathLength {
-				position = find(path, star, i, pathLength)
-				res = res.insertChild(path[i], newResourceFromPath(path[i:position]))
-
-				if position < pathLength {
-					res = res.insertChild(star, newResourceFromPath(path[position+1:]))
-					max++
-				}
+// callAfter calls the after handlers of the router which haven't been called using Next, each in turn.
+// This makes them always run, like defer, even though a handler didn't call Next or aborted the chain
+func (r *Router) callAfter(c *Context) {
+	for next := c.currentHandler + 1; next < c.maxHandlers; next = c.currentHandler + 1 {
+		if next < c.afterIndex {
+			next = c.afterIndex
+		}
 
-				res.addMethods(methods, options, handlers...)
-				break
-			}
+		c.currentHandler = next
+		c.callByIndex(next)
+	}
+}
 
-			res = res.insertChild(path[i], newResourceFromPath(path[i:position]))
-			i = find(path, slash, position, pathLength)
-			res = res.insertChild(colon, newResourceFromPath(path[position+1:i]))
-			max++
+// recover recovers panics in the handler chain, passing them to the PanicHandler with status http.StatusInternalServerError,
+// and calls the remaining after handlers of the router. Panics in either are logged and not propagated.
+// http.ErrAbortHandler is panicked again, to let the server abort the response
+func (r *Router) recover(c *Context) {
+	recovered := recover()
+	if recovered == nil {
+		return
+	}
 
-			if i == pathLength {
-				res.addMethods(methods, options, handlers...)
-			}
+	if recovered == http.ErrAbortHandler || r.PanicHandler == nil {
+		panic(recovered)
+	}
 
-		} else if path[i] == colon {
-			res = res.children[0]
-			i += len(res.path) + 1
-			max++
+	// The status is kept, if the response was written before panicking
+	if !c.Written() {
+		c.status = http.StatusInternalServerError
+	}
 
-			if i == pathLength {
-				res.addMethods(methods, options, handlers...)
-			}
+	// A panic in the PanicHandler, e.g. rendering a written response with PanicOnWrite, doesn't stop the after handlers
+	r.contain(c, "Panic recovered in the panic handler", func() {
+		r.PanicHandler(c, recovered)
+	})
 
-		} else {
-			res = res.getChild(path[i])
+	// Resuming after the panicking after handler, if any
+	for c.currentHandler+1 < c.maxHandlers {
+		r.contain(c, "Panic recovered in an after handler", func() {
+			r.callAfter(c)
+		})
+	}
+}
 
-			j, resourcePathLength := 0, len(res.path)
-			for j < resourcePathLength && i < pathLength && path[i] == res.path[j] {
-				i++
-				j++
+// contain calls the function, logging a panic instead of propagating it, unless it's http.ErrAbortHandler
+func (r *Router) contain(c *Context, msg string, fn func()) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			if recovered == http.ErrAbortHandler {
+				panic(recovered)
 			}
+			r.panicLog().Error(msg, "panic", recovered, "method", c.request.Method, "path", c.request.URL.Path)
+		}
+	}()
 
-			if j < resourcePathLength {
-				child := res.copy()
-				child.path = res.path[j:]
+	fn()
+}
 
-				// Why cant i simplify this with newResource?
-				res.path = res.path[:j]
-				res.methods = make(map[string][]ResponseHandler)
-				res.prefix = string(child.path[0])
-				res.children = []*resource{child}
-				res.options = &Options{}
-			}
+func (r *Router) addRoute(path string, methods []string, handlers ...ResponseHandler) *Options {
+	return r.insertRoute(nil, path, methods, handlers...)
+}
 
-			if i == pathLength {
-				res.addMethods(methods, options, handlers...)
+// insertRoute inserts the route into the tree, belonging to the group if not nil
+func (r *Router) insertRoute(group *Group, path string, methods []string, handlers ...ResponseHandler) *Options {
+	i, pathLength, res, max := 0, len(path), r.res, 0
+	options := &Options{path: path, router: r, group: group}
+
+	for i < pathLength {
+		switch path[i] {
+		case colon:
+			position := find(path, slash, i, pathLength)
+			res = res.insertParameter(path[i+1 : position])
+			i = position
+			max++
+		case star:
+			res = res.insertCatchAll(path[i+1:])
+			i = pathLength
+			max++
+		default:
+			// Static part runs until the next parameter or catch-all
+			position := find(path, colon, i, pathLength)
+			if catchAll := find(path, star, i, position); catchAll < position {
+				position = catchAll
 			}
+			res = res.insertStatic(path[i:position])
+			i = position
 		}
 	}
 
+	res.addMethods(methods, options, handlers...)
+	r.buildChains(res)
+
 	if r.res.max < max {
 		r.res.max = max
 	}
@@ -226,54 +585,74 @@ func (r *Router) appendParameter(parameters *Parameters, key, value string) {
 	parameters.stack = append(parameters.stack, parameter{key, value})
 }
 
+// findRoute finds the handlers for the given path and method.
+// Found is true when the path exists, even though no handlers exist for the method
 func (r *Router) findRoute(path, method string) (found bool, handlers []ResponseHandler, parameters Parameters) {
-	// TODO - Make params object instead of map
-	i, pathLength, res, parameters := 0, len(path), r.res, Parameters{}
-
-	for i < pathLength {
+	if res := r.lookup(path, method, &parameters); res != nil {
+		return true, res.methods[method], parameters
+	}
 
-		if len(res.prefix) == 0 {
-			return
-		}
+	return r.lookup(path, "", &parameters) != nil, nil, parameters
+}
 
-		if res.prefix[0] == colon {
-			res = res.children[0]
-			position := find(path, slash, i, len(path))
-			r.appendParameter(&parameters, res.path, path[i:position])
-			i = position
-		} else if res.prefix[0] == star {
-			res = res.children[0]
-			r.appendParameter(&parameters, res.path, path[i:])
-			break
-		} else {
-			position := res.getIndexPosition(path[i])
+// lookup walks the tree and returns the resource with a route for the method matching the path,
+// filling in the parameters. An empty method matches a route of any method.
+// The stack of the parameters is reset and reused. Returns a nil resource when no match was found
+func (r *Router) lookup(path, method string, parameters *Parameters) *resource {
+	parameters.stack = parameters.stack[:0]
+	if res := r.match(r.res, path, method, parameters); res != nil {
+		return res
+	}
 
-			if position == len(res.prefix) || res.prefix[position] != path[i] {
-				return
-			}
-			res = res.children[position]
-			position = i + len(res.path)
+	parameters.stack = parameters.stack[:0]
+	return nil
+}
 
-			if position > pathLength || path[i:position] != res.path {
-				return
-			}
-			i = position
+// match recursively finds the resource matching the rest of the path below res.
+// Static children have priority over parameters, which have priority over the catch-all.
+// Parameter children are tried in order of insertion. Whenever a branch doesn't match, has no route for the method
+// or the constraints of the route are not satisfied, the next one is tried, so the first satisfied constraint wins
+func (r *Router) match(res *resource, path, method string, parameters *Parameters) *resource {
+	if len(path) == 0 {
+		if res.satisfies(method, *parameters) {
+			return res
 		}
+		return nil
+	}
 
+	if child := res.getChild(path[0]); child != nil && strings.HasPrefix(path, child.path) {
+		if found := r.match(child, path[len(child.path):], method, parameters); found != nil {
+			return found
+		}
 	}
 
-	// If regex is specified, we will run it against the parameters
-	if res.options.regex != nil {
-		for name, constraint := range res.options.regex {
-			if ok, param := parameters.GetByName(name); ok {
-				validRoute := regexp.MustCompile(constraint)
-				if !validRoute.MatchString(param) {
-					// Not found
-					return
+	if len(res.parameters) > 0 {
+		position := find(path, slash, 0, len(path))
+		if position > 0 {
+			for _, child := range res.parameters {
+				stackLength := len(parameters.stack)
+				r.appendParameter(parameters, child.path, path[:position])
+
+				if found := r.match(child, path[position:], method, parameters); found != nil {
+					return found
 				}
+
+				// Backtracking, removing the parameter again
+				parameters.stack = parameters.stack[:stackLength]
 			}
 		}
 	}
 
-	return true, res.methods[method], parameters
+	if res.catchAll != nil {
+		stackLength := len(parameters.stack)
+		r.appendParameter(parameters, res.catchAll.path, path)
+
+		if res.catchAll.satisfies(method, *parameters) {
+			return res.catchAll
+		}
+
+		parameters.stack = parameters.stack[:stackLength]
+	}
+
+	return nil
 }
@@ -1,8 +1,11 @@
 package fit
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"runtime/debug"
 	"strings"
 )
 
@@ -16,6 +19,59 @@ func (r *Router) Post(path string, handlers ...ResponseHandler) *Options {
 	return r.addRoute(path, []string{"POST"}, handlers...)
 }
 
+// Put - helper method for adding routes accessible via put method
+func (r *Router) Put(path string, handlers ...ResponseHandler) *Options {
+	return r.addRoute(path, []string{"PUT"}, handlers...)
+}
+
+// Patch - helper method for adding routes accessible via patch method
+func (r *Router) Patch(path string, handlers ...ResponseHandler) *Options {
+	return r.addRoute(path, []string{"PATCH"}, handlers...)
+}
+
+// Delete - helper method for adding routes accessible via delete method
+func (r *Router) Delete(path string, handlers ...ResponseHandler) *Options {
+	return r.addRoute(path, []string{"DELETE"}, handlers...)
+}
+
+// Head - helper method for adding routes accessible via head method
+func (r *Router) Head(path string, handlers ...ResponseHandler) *Options {
+	return r.addRoute(path, []string{"HEAD"}, handlers...)
+}
+
+// Options - helper method for adding routes accessible via options method
+func (r *Router) Options(path string, handlers ...ResponseHandler) *Options {
+	return r.addRoute(path, []string{"OPTIONS"}, handlers...)
+}
+
+// Connect - helper method for adding routes accessible via connect method
+func (r *Router) Connect(path string, handlers ...ResponseHandler) *Options {
+	return r.addRoute(path, []string{"CONNECT"}, handlers...)
+}
+
+// Trace - helper method for adding routes accessible via trace method
+func (r *Router) Trace(path string, handlers ...ResponseHandler) *Options {
+	return r.addRoute(path, []string{"TRACE"}, handlers...)
+}
+
+// Any - helper method for adding routes accessible via all the standard methods
+func (r *Router) Any(path string, handlers ...ResponseHandler) *Options {
+	return r.addRoute(path, anyMethods, handlers...)
+}
+
+// Match - helper method for adding routes accessible via the given methods
+func (r *Router) Match(methods []string, path string, handlers ...ResponseHandler) *Options {
+	upper := make([]string, len(methods))
+	for i, method := range methods {
+		upper[i] = strings.ToUpper(method)
+	}
+
+	return r.addRoute(path, upper, handlers...)
+}
+
+// All the standard methods, used by Any
+var anyMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "HEAD", "OPTIONS", "CONNECT", "TRACE"}
+
 // Route find
 func find(src string, target byte, start int, pathLength int) int {
 	i := start
@@ -25,15 +81,36 @@ func find(src string, target byte, start int, pathLength int) int {
 	return i
 }
 
-// Print tree - prints the radix tree
+// Print tree - logs the routes of the radix tree at debug level using the Logger of the router
 func (r *Router) PrintTree() {
+	r.res.walk(func(res *resource) {
+		if methods := res.allowedMethods(); len(methods) > 0 {
+			r.log().Debug("Route", "path", res.options[methods[0]].path, "methods", methods)
+		}
+	})
+}
+
+// WriteTree writes the radix tree to the writer
+func (r *Router) WriteTree(w io.Writer) {
 	res := r.res
-	printResource(res, 1, false)
+	printResource(w, res, 1, false)
 }
 
-func printResource(res *resource, amount int, pre bool) {
-	total := len(res.children)
-	for i, r := range res.children {
+func printResource(w io.Writer, res *resource, amount int, pre bool) {
+	// Parameters and catch-all are printed after the static children, prefixed with their identifier
+	children, names := make([]*resource, 0, len(res.children)+len(res.parameters)+1), []string{}
+	for _, r := range res.children {
+		children, names = append(children, r), append(names, r.path)
+	}
+	for _, r := range res.parameters {
+		children, names = append(children, r), append(names, string(colon)+r.path)
+	}
+	if res.catchAll != nil {
+		children, names = append(children, res.catchAll), append(names, string(star)+res.catchAll.path)
+	}
+
+	total := len(children)
+	for i, r := range children {
 		var methods string
 		if len(r.methods) > 0 {
 			keys := make([]string, 0, len(r.methods))
@@ -60,10 +137,10 @@ func printResource(res *resource, amount int, pre bool) {
 			spacing += "    "
 		}
 
-		fmt.Println(spacing, flag, r.path, methods)
+		fmt.Fprintln(w, spacing, flag, names[i], methods)
 
-		if len(r.children) > 0 {
-			printResource(r, amount+1, total != i+1)
+		if len(r.children) > 0 || len(r.parameters) > 0 || r.catchAll != nil {
+			printResource(w, r, amount+1, total != i+1)
 		}
 	}
 }
@@ -71,9 +148,80 @@ func printResource(res *resource, amount int, pre bool) {
 func notFoundHandler() ResponseHandler {
 
 	return func(c *Context) {
-		response := map[string]string{
-			"message": "The URL you've requested was not found.",
+		c.Error(NewHTTPError(http.StatusNotFound, "The URL you've requested was not found."))
+	}
+}
+
+func methodNotAllowedHandler() ResponseHandler {
+
+	return func(c *Context) {
+		c.Error(NewHTTPError(http.StatusMethodNotAllowed, "The method you've requested is not allowed for this URL."))
+	}
+}
+
+func notAcceptableHandler() ResponseHandler {
+
+	return func(c *Context) {
+		c.Error(NewHTTPError(http.StatusNotAcceptable, "None of the requested content types are available."))
+	}
+}
+
+// errorHandler maps the error to a status code and renders it as JSON.
+// Errors not known by the router are rendered as http.StatusInternalServerError, without leaking the error
+func errorHandler() func(c *Context, err error) {
+
+	return func(c *Context, err error) {
+		// Nothing can be rendered once the response has been written
+		if c.Written() {
+			return
+		}
+
+		var (
+			httpError       *HTTPError
+			fieldErrors     ValidationErrors
+			validationError *ValidationError
+			bindingError    *BindingError
+			parameterError  *ParameterError
+		)
+
+		switch {
+		case errors.As(err, &httpError):
+			c.JSON(map[string]string{"message": httpError.Message}, httpError.Code)
+		case errors.As(err, &fieldErrors):
+			response := map[string]interface{}{
+				"message": "The given data was invalid.",
+				"errors":  fieldErrors,
+			}
+			c.JSON(response, http.StatusUnprocessableEntity)
+		case errors.As(err, &validationError):
+			c.JSON(map[string]string{"message": err.Error()}, http.StatusUnprocessableEntity)
+		case errors.Is(err, ErrUnsupportedMediaType):
+			c.JSON(map[string]string{"message": err.Error()}, http.StatusUnsupportedMediaType)
+		case errors.As(err, &bindingError), errors.As(err, &parameterError):
+			c.JSON(map[string]string{"message": err.Error()}, http.StatusBadRequest)
+		default:
+			response := map[string]string{
+				"message": "The server encountered an internal error.",
+			}
+			c.JSON(response, http.StatusInternalServerError)
+		}
+	}
+}
+
+// panicHandler logs the recovered value with the stack trace using the Logger of the router,
+// and passes a PanicError to the error handler, unless the response has been written
+func panicHandler() func(c *Context, recovered interface{}) {
+
+	return func(c *Context, recovered interface{}) {
+		// Panics in routes with a timeout are recovered in the goroutine of the handlers, holding their stack
+		err, ok := recovered.(*PanicError)
+		if !ok {
+			err = &PanicError{recovered, debug.Stack()}
+		}
+		c.router.panicLog().Error("Panic recovered", "panic", err.Recovered, "method", c.request.Method, "path", c.request.URL.Path, "stack", string(err.Stack))
+
+		if !c.Written() {
+			c.Error(err)
 		}
-		c.JSON(response, http.StatusNotFound)
 	}
 }
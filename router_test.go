@@ -1,17 +1,23 @@
 package fit
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"io/ioutil"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 )
 
 var (
 	router            = NewRouter()
 	fourOhFourMessage = "The URL you've requested was not found."
+	internalMessage   = "The server encountered an internal error."
 )
 
 func TestRouterInitialization(t *testing.T) {
@@ -191,7 +197,7 @@ func TestRouteRegex(t *testing.T) {
 func findRoute(route testRoute, t *testing.T) {
 	req := httptest.NewRequest(route.method, route.visitRoute, nil)
 	w := httptest.NewRecorder()
-	router.request(w, req)
+	router.ServeHTTP(w, req)
 
 	resp := w.Result()
 	body, _ := ioutil.ReadAll(resp.Body)
@@ -200,10 +206,16 @@ func findRoute(route testRoute, t *testing.T) {
 		t.Errorf("Status for '%s' code is wrong. Expected %d, got %d", route.visitRoute, route.expectedStatus, resp.StatusCode)
 	}
 
+	// Failures are rendered by the error handler of the router, without leaking the error
+	expectedMessage := route.expectedMessage
+	if route.expectedStatus == http.StatusInternalServerError {
+		expectedMessage = internalMessage
+	}
+
 	var m testMessage
 	err := json.Unmarshal(body, &m)
-	if err == nil && m.Message != route.expectedMessage {
-		t.Errorf("Message in body is wrong for '%s'. Expected '%s', got '%s'", route.visitRoute, route.expectedMessage, m.Message)
+	if err == nil && m.Message != expectedMessage {
+		t.Errorf("Message in body is wrong for '%s'. Expected '%s', got '%s'", route.visitRoute, expectedMessage, m.Message)
 	}
 
 	if route.expectedParameters != nil && err == nil {
@@ -213,3 +225,501 @@ func findRoute(route testRoute, t *testing.T) {
 	}
 
 }
+
+func TestRouterAsHandler(t *testing.T) {
+	var handler http.Handler = router
+
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/photos")
+	if err != nil {
+		t.Fatalf("Request to test server failed: %s", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Status code is wrong. Expected %d, got %d", http.StatusOK, resp.StatusCode)
+	}
+}
+
+func TestRouterRunAndGracefulShutdown(t *testing.T) {
+	r := NewRouter()
+	started, release := make(chan bool), make(chan bool)
+	r.Get("/slow", func(c *Context) {
+		started <- true
+		<-release
+		c.JSON("done")
+	})
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Could not create listener: %s", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	runErr := make(chan error, 1)
+	go func() {
+		runErr <- r.RunListener(ctx, listener)
+	}()
+
+	status := make(chan int, 1)
+	go func() {
+		resp, err := http.Get("http://" + listener.Addr().String() + "/slow")
+		if err != nil {
+			status <- 0
+			return
+		}
+		resp.Body.Close()
+		status <- resp.StatusCode
+	}()
+
+	// Cancel while the request is in-flight, it should still be answered
+	<-started
+	cancel()
+	release <- true
+
+	if code := <-status; code != http.StatusOK {
+		t.Errorf("In-flight request was not drained. Expected %d, got %d", http.StatusOK, code)
+	}
+
+	if err := <-runErr; err != nil {
+		t.Errorf("Expected run to return nil after shutdown, got '%s'", err)
+	}
+}
+
+func TestRouterShutdown(t *testing.T) {
+	r := NewRouter()
+
+	if err := r.Shutdown(context.Background()); err != nil {
+		t.Errorf("Shutdown of a stopped router should not fail, got '%s'", err)
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Could not create listener: %s", err)
+	}
+
+	// Shutdown was requested before running, so it should return at once
+	if err := r.RunListener(context.Background(), listener); err != nil {
+		t.Errorf("Expected run to return nil after an early shutdown, got '%s'", err)
+	}
+
+	listener, err = net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Could not create listener: %s", err)
+	}
+
+	runErr := make(chan error, 1)
+	go func() {
+		runErr <- r.RunListener(context.Background(), listener)
+	}()
+
+	// Wait for the server to be registered before shutting it down
+	for {
+		r.mu.Lock()
+		running := r.server != nil
+		r.mu.Unlock()
+		if running {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	if err := r.Shutdown(context.Background()); err != nil {
+		t.Errorf("Expected shutdown to succeed, got '%s'", err)
+	}
+
+	if err := <-runErr; err != nil {
+		t.Errorf("Expected run to return nil after shutdown, got '%s'", err)
+	}
+}
+
+func TestRouterShutdownTimeout(t *testing.T) {
+	r := NewRouter()
+	r.ShutdownTimeout = 20 * time.Millisecond
+
+	started, release := make(chan bool), make(chan bool)
+	defer close(release)
+	r.Get("/stuck", func(c *Context) {
+		started <- true
+		<-release
+	})
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Could not create listener: %s", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	runErr := make(chan error, 1)
+	go func() {
+		runErr <- r.RunListener(ctx, listener)
+	}()
+
+	go http.Get("http://" + listener.Addr().String() + "/stuck")
+
+	<-started
+	cancel()
+
+	select {
+	case err := <-runErr:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Expected the draining to time out, got '%v'", err)
+		}
+	case <-time.After(time.Second):
+		t.Error("Run should return when the draining times out")
+	}
+}
+
+func TestMethodHelpers(t *testing.T) {
+	r := NewRouter()
+	handler := func(c *Context) {
+		c.JSON(c.Request().Method)
+	}
+
+	r.Get("/methods", handler)
+	r.Post("/methods", handler)
+	r.Put("/methods", handler)
+	r.Patch("/methods", handler)
+	r.Delete("/methods", handler)
+	r.Head("/methods", handler)
+	r.Options("/methods", handler)
+	r.Connect("/methods", handler)
+	r.Trace("/methods", handler)
+	r.Any("/any", handler)
+	r.Match([]string{"get", "POST"}, "/match", handler)
+
+	for _, method := range anyMethods {
+		for _, path := range []string{"/methods", "/any"} {
+			if found, handlers, _ := r.findRoute(path, method); !found || handlers == nil {
+				t.Errorf("Expected '%s %s' to be registered", method, path)
+			}
+		}
+	}
+
+	for method, expected := range map[string]bool{"GET": true, "POST": true, "PUT": false} {
+		if _, handlers, _ := r.findRoute("/match", method); (handlers != nil) != expected {
+			t.Errorf("Registration of '%s /match' is wrong. Expected %t, got %t", method, expected, handlers != nil)
+		}
+	}
+
+	// Authority-form CONNECT requests have an empty path
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("CONNECT", "example.com:443", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Status code for authority-form CONNECT is wrong. Expected %d, got %d", http.StatusNotFound, w.Code)
+	}
+}
+
+func TestMethodNotAllowed(t *testing.T) {
+	r := NewRouter()
+	handler := func(c *Context) {
+		c.JSON("ok")
+	}
+	r.Get("/articles/:id", handler)
+	r.Delete("/articles/:id", handler)
+
+	req := httptest.NewRequest("POST", "/articles/2", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Status code is wrong. Expected %d, got %d", http.StatusMethodNotAllowed, w.Code)
+	}
+
+	if allow := w.Header().Get("Allow"); allow != "DELETE, GET" {
+		t.Errorf("Allow header is wrong. Expected '%s', got '%s'", "DELETE, GET", allow)
+	}
+
+	r.MethodNotAllowed = func(c *Context) {
+		c.JSON("custom", http.StatusMethodNotAllowed)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	var message string
+	if err := json.Unmarshal(w.Body.Bytes(), &message); err != nil || message != "custom" {
+		t.Errorf("Custom method not allowed handler was not called, got '%s'", w.Body.String())
+	}
+}
+
+func TestAutomaticOptionsAndHead(t *testing.T) {
+	r := NewRouter()
+	r.Get("/books", func(c *Context) {
+		c.Writer().Header().Set("X-Books", "yes")
+		c.JSON("books")
+	})
+	r.Post("/books", func(c *Context) {
+		c.JSON("created", http.StatusCreated)
+	})
+
+	// Disabled pr. default
+	for _, method := range []string{"OPTIONS", "HEAD"} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(method, "/books", nil))
+		if w.Code != http.StatusMethodNotAllowed {
+			t.Errorf("Status code for %s is wrong. Expected %d, got %d", method, http.StatusMethodNotAllowed, w.Code)
+		}
+	}
+
+	r.HandleOPTIONS, r.HandleHEAD = true, true
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("OPTIONS", "/books", nil))
+	if w.Code != http.StatusNoContent {
+		t.Errorf("Status code for OPTIONS is wrong. Expected %d, got %d", http.StatusNoContent, w.Code)
+	}
+	if allow := w.Header().Get("Allow"); allow != "GET, HEAD, OPTIONS, POST" {
+		t.Errorf("Allow header is wrong. Expected '%s', got '%s'", "GET, HEAD, OPTIONS, POST", allow)
+	}
+
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	get, err := http.Get(server.URL + "/books")
+	if err != nil {
+		t.Fatal(err)
+	}
+	get.Body.Close()
+
+	head, err := http.Head(server.URL + "/books")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := ioutil.ReadAll(head.Body)
+	head.Body.Close()
+
+	if head.StatusCode != http.StatusOK || head.Header.Get("X-Books") != "yes" {
+		t.Errorf("HEAD was not served by the GET handlers, got status %d", head.StatusCode)
+	}
+	if len(body) != 0 {
+		t.Errorf("HEAD should not write a body, got '%s'", body)
+	}
+	if head.ContentLength != get.ContentLength || head.ContentLength <= 0 {
+		t.Errorf("Content-Length of HEAD is wrong. Expected %d, got %d", get.ContentLength, head.ContentLength)
+	}
+
+	r.GlobalOPTIONS = func(c *Context) {
+		c.Writer().Header().Set("Access-Control-Allow-Methods", c.Writer().Header().Get("Allow"))
+		c.JSON(nil, http.StatusOK)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("OPTIONS", "/books", nil))
+	if w.Code != http.StatusOK || w.Header().Get("Access-Control-Allow-Methods") != "GET, HEAD, OPTIONS, POST" {
+		t.Errorf("GlobalOPTIONS was not called, got status %d", w.Code)
+	}
+}
+
+func TestInvalidRouteRegex(t *testing.T) {
+	r := NewRouter()
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Registering an invalid constraint should panic")
+		}
+	}()
+
+	r.Get("/invalid-regex/:id", nil).Where("id", "^[0-9")
+}
+
+func TestConstraintFallthrough(t *testing.T) {
+	r := NewRouter()
+	respond := func(name string) ResponseHandler {
+		return func(c *Context) {
+			_, value := c.Parameters().GetByName(name)
+			c.JSON(name + "=" + value)
+		}
+	}
+
+	r.Get("/user/:id", respond("id")).Where("id", "^[0-9]+$")
+	r.Get("/user/:name", respond("name")).Where("name", "^[a-z]+$")
+	r.Get("/user/:slug", respond("slug"))
+
+	tests := map[string]string{
+		"/user/42":    "id=42",
+		"/user/brian": "name=brian",
+		"/user/Br-1":  "slug=Br-1",
+	}
+
+	for path, expected := range tests {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+
+		var message string
+		if err := json.Unmarshal(w.Body.Bytes(), &message); err != nil || message != expected {
+			t.Errorf("Wrong route matched for '%s'. Expected '%s', got '%s'", path, expected, w.Body.String())
+		}
+	}
+
+	// Backtracking must also work when the constraint fails further down the path
+	r.Get("/post/:id/comments/:comment", respond("comment")).Where("comment", "^[0-9]+$")
+	r.Get("/post/:slug/comments/:title", respond("title"))
+
+	found, _, params := r.findRoute("/post/22/comments/first", "GET")
+	expectedParams := Parameters{[]parameter{{"slug", "22"}, {"title", "first"}}}
+	if !found || !reflect.DeepEqual(params, expectedParams) {
+		t.Errorf("Expected to find params %s, found %s", expectedParams, params)
+	}
+}
+
+func TestConstraintsPerMethod(t *testing.T) {
+	r := NewRouter()
+	handler := func(c *Context) {
+		c.JSON(c.Request().Method)
+	}
+
+	r.Get("/n/:id", handler).Where("id", "^[0-9]+$")
+	r.Post("/n/:id", handler)
+
+	tests := []struct {
+		method string
+		path   string
+		status int
+	}{
+		{"GET", "/n/42", http.StatusOK},
+		{"GET", "/n/abc", http.StatusMethodNotAllowed},
+		{"POST", "/n/abc", http.StatusOK},
+	}
+
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(test.method, test.path, nil))
+
+		if w.Code != test.status {
+			t.Errorf("Status code for '%s %s' is wrong. Expected %d, got %d", test.method, test.path, test.status, w.Code)
+		}
+	}
+}
+
+func TestBacktrackingByMethod(t *testing.T) {
+	r := NewRouter()
+	respond := func(route string) ResponseHandler {
+		return func(c *Context) {
+			c.JSON(route)
+		}
+	}
+
+	r.Get("/user/:id", respond("GET /user/:id"))
+	r.Post("/user/:name", respond("POST /user/:name"))
+	r.Get("/users/me", respond("GET /users/me"))
+	r.Post("/users/:id", respond("POST /users/:id"))
+
+	tests := map[string]string{
+		"GET /user/5":    "GET /user/:id",
+		"POST /user/5":   "POST /user/:name",
+		"GET /users/me":  "GET /users/me",
+		"POST /users/me": "POST /users/:id",
+	}
+
+	for request, expected := range tests {
+		parts := strings.Split(request, " ")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(parts[0], parts[1], nil))
+
+		var message string
+		if err := json.Unmarshal(w.Body.Bytes(), &message); err != nil || message != expected {
+			t.Errorf("Wrong route matched for '%s'. Expected '%s', got %d '%s'", request, expected, w.Code, w.Body.String())
+		}
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("PUT", "/users/me", nil))
+	if w.Code != http.StatusMethodNotAllowed || w.Header().Get("Allow") != "GET" {
+		t.Errorf("Expected 405 with the methods of the first matching path, got %d '%s'", w.Code, w.Header().Get("Allow"))
+	}
+}
+
+func TestStaticPriority(t *testing.T) {
+	r := NewRouter()
+	respond := func(route string) ResponseHandler {
+		return func(c *Context) {
+			c.JSON(route)
+		}
+	}
+
+	r.Get("/users/:id", respond("/users/:id"))
+	r.Get("/users/me", respond("/users/me"))
+	r.Get("/users/:id/posts", respond("/users/:id/posts"))
+	r.Get("/users/me/settings", respond("/users/me/settings"))
+	r.Get("/users/*path", respond("/users/*path"))
+	r.Get("/files/*path", respond("/files/*path"))
+	r.Get("/files/readme", respond("/files/readme"))
+
+	tests := map[string]string{
+		"/users/me":          "/users/me",
+		"/users/mario":       "/users/:id",
+		"/users/m":           "/users/:id",
+		"/users/me/settings": "/users/me/settings",
+		"/users/me/posts":    "/users/:id/posts",
+		"/users/22/posts":    "/users/:id/posts",
+		"/users/22/comments": "/users/*path",
+		"/files/readme":      "/files/readme",
+		"/files/readme.md":   "/files/*path",
+		"/files/docs/readme": "/files/*path",
+	}
+
+	for path, expected := range tests {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+
+		var message string
+		if err := json.Unmarshal(w.Body.Bytes(), &message); err != nil || message != expected {
+			t.Errorf("Wrong route matched for '%s'. Expected '%s', got '%s'", path, expected, w.Body.String())
+		}
+	}
+
+	if _, _, params := r.findRoute("/users/me/posts", "GET"); !reflect.DeepEqual(params, Parameters{[]parameter{{"id", "me"}}}) {
+		t.Errorf("Parameters were not reset when backtracking, got %s", params)
+	}
+}
+
+func TestJSONEncodingOptions(t *testing.T) {
+	r := NewRouter()
+	r.Get("/json", func(c *Context) {
+		c.JSON(map[string]string{"html": "<b>"})
+	})
+	r.Get("/broken-json", func(c *Context) {
+		c.JSON(make(chan int))
+	})
+
+	tests := []struct {
+		indent     string
+		escapeHTML bool
+		body       string
+	}{
+		{"", true, "{\"html\":\"\\u003cb\\u003e\"}\n"},
+		{"", false, "{\"html\":\"<b>\"}\n"},
+		{"  ", false, "{\n  \"html\": \"<b>\"\n}\n"},
+	}
+
+	for _, test := range tests {
+		r.JSONIndent, r.JSONEscapeHTML = test.indent, test.escapeHTML
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", "/json", nil))
+
+		if contentType := w.Header().Get("Content-Type"); contentType != "application/json; charset=utf-8" {
+			t.Errorf("Content-Type is wrong. Expected '%s', got '%s'", "application/json; charset=utf-8", contentType)
+		}
+
+		if w.Body.String() != test.body {
+			t.Errorf("Body is wrong. Expected '%s', got '%s'", test.body, w.Body.String())
+		}
+	}
+
+	var handledErr error
+	r.ErrorHandler = func(c *Context, err error) {
+		handledErr = err
+		c.String("custom", http.StatusServiceUnavailable)
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/broken-json", nil))
+
+	if handledErr == nil || w.Code != http.StatusServiceUnavailable || w.Body.String() != "custom" {
+		t.Errorf("Encoding failure was not passed to the error handler, got %d '%s'", w.Code, w.Body.String())
+	}
+}
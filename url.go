@@ -0,0 +1,73 @@
+package fit
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// URL generates the path for the route with the given name, using the supplied parameters.
+// Parameters are given as pairs of name and value, like Options.Where, e.g. URL("user.show", "username", "brian").
+// An error is returned if a parameter is missing, unknown or does not satisfy the constraint of the route
+func (r *Router) URL(name string, parameters ...string) (string, error) {
+	options, ok := r.names[name]
+	if !ok {
+		return "", fmt.Errorf("fit: no route named '%s'", name)
+	}
+
+	if len(parameters)%2 != 0 {
+		return "", fmt.Errorf("fit: parameter value is missing for route '%s'", name)
+	}
+
+	values := make(map[string]string, len(parameters)/2)
+	for i := 0; i < len(parameters); i += 2 {
+		values[parameters[i]] = parameters[i+1]
+	}
+
+	path, pathLength := options.path, len(options.path)
+	var b strings.Builder
+	used := 0
+
+	for i := 0; i < pathLength; {
+		if path[i] != colon && path[i] != star {
+			b.WriteByte(path[i])
+			i++
+			continue
+		}
+
+		end := find(path, slash, i, pathLength)
+		if path[i] == star {
+			end = pathLength
+		}
+
+		key := path[i+1 : end]
+		value, ok := values[key]
+		if !ok {
+			return "", fmt.Errorf("fit: parameter '%s' is missing for route '%s'", key, name)
+		}
+
+		if constraint, ok := options.regex[key]; ok && !constraint.MatchString(value) {
+			return "", fmt.Errorf("fit: parameter '%s' with value '%s' does not match '%s' for route '%s'", key, value, constraint, name)
+		}
+
+		if path[i] == star {
+			// Catch-all values may contain slashes, so only the segments are escaped
+			segments := strings.Split(value, "/")
+			for j, segment := range segments {
+				segments[j] = url.PathEscape(segment)
+			}
+			b.WriteString(strings.Join(segments, "/"))
+		} else {
+			b.WriteString(url.PathEscape(value))
+		}
+
+		used++
+		i = end
+	}
+
+	if used < len(values) {
+		return "", fmt.Errorf("fit: too many parameters supplied for route '%s'", name)
+	}
+
+	return b.String(), nil
+}
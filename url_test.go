@@ -0,0 +1,48 @@
+package fit
+
+import "testing"
+
+func TestURL(t *testing.T) {
+	r := NewRouter()
+	r.Get("/user/:username", nil).Name("user.show").Where("username", "^[a-z]*$")
+	r.Get("/files/:owner/*path", nil).Name("files.show")
+
+	tests := []struct {
+		name       string
+		parameters []string
+		expected   string
+		valid      bool
+	}{
+		{"user.show", []string{"username", "brian"}, "/user/brian", true},
+		{"files.show", []string{"owner", "john doe", "path", "docs/a b.txt"}, "/files/john%20doe/docs/a%20b.txt", true},
+		{"user.show", []string{"username", "brian42"}, "", false},
+		{"user.show", []string{}, "", false},
+		{"user.show", []string{"username"}, "", false},
+		{"user.show", []string{"username", "brian", "extra", "value"}, "", false},
+		{"does.not.exist", []string{}, "", false},
+	}
+
+	for _, test := range tests {
+		url, err := r.URL(test.name, test.parameters...)
+		if test.valid && (err != nil || url != test.expected) {
+			t.Errorf("URL for '%s' is wrong. Expected '%s', got '%s' (%v)", test.name, test.expected, url, err)
+		}
+
+		if !test.valid && err == nil {
+			t.Errorf("URL for '%s' with %s should fail, got '%s'", test.name, test.parameters, url)
+		}
+	}
+}
+
+func TestDuplicateRouteName(t *testing.T) {
+	r := NewRouter()
+	r.Get("/a", nil).Name("duplicate")
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Naming two routes the same should panic")
+		}
+	}()
+
+	r.Get("/b", nil).Name("duplicate")
+}
@@ -0,0 +1,309 @@
+package fit
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Validator validates a value, typically a struct populated by Bind.
+// Implement it to bring your own validation, and set it on Router.Validator
+type Validator interface {
+	Validate(v interface{}) error
+}
+
+// ErrUnknownRule is returned by TagValidator when a tag uses a rule which doesn't exist.
+// It's a mistake in the code rather than in the request, so the default error handler answers with http.StatusInternalServerError
+var ErrUnknownRule = errors.New("validation rule does not exist")
+
+// Nested time.Time fields are validated as values, not as structs
+var timeType = reflect.TypeOf(time.Time{})
+
+// ValidationRule checks the value of a field against the parameter of the rule, e.g. "3" for "min=3".
+// Returns false when the value is invalid
+type ValidationRule func(field reflect.Value, param string) bool
+
+// FieldError describes a field not satisfying a rule
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
+}
+
+// ValidationErrors is returned when one or more fields are invalid
+type ValidationErrors []FieldError
+
+func (e ValidationErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, fieldError := range e {
+		messages[i] = fieldError.Message
+	}
+
+	return strings.Join(messages, ", ")
+}
+
+// ValidationError wraps an error returned by a custom Validator which isn't ValidationErrors,
+// making the default error handler answer with http.StatusUnprocessableEntity
+type ValidationError struct {
+	Err error
+}
+
+func (e *ValidationError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// TagValidator is the default Validator, validating structs using the `validate` tag,
+// e.g. `validate:"required,min=3,email"`. Rules are separated by commas, parameters given after "="
+type TagValidator struct {
+	rules map[string]ValidationRule
+}
+
+// NewTagValidator returns a TagValidator with the built-in rules:
+// required, min, max, len, email, oneof, numeric and alpha
+func NewTagValidator() *TagValidator {
+	return &TagValidator{map[string]ValidationRule{
+		"required": func(field reflect.Value, _ string) bool { return !field.IsZero() },
+		"min":      compareRule(func(value, param float64) bool { return value >= param }),
+		"max":      compareRule(func(value, param float64) bool { return value <= param }),
+		"len":      compareRule(func(value, param float64) bool { return value == param }),
+		"email":    stringRule(func(value, _ string) bool { return isEmail(value) }),
+		"oneof": stringRule(func(value, param string) bool {
+			for _, option := range strings.Fields(param) {
+				if value == option {
+					return true
+				}
+			}
+			return false
+		}),
+		"numeric": stringRule(func(value, _ string) bool {
+			_, err := strconv.ParseFloat(value, 64)
+			return err == nil
+		}),
+		"alpha": stringRule(func(value, _ string) bool {
+			for _, r := range value {
+				if (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') {
+					return false
+				}
+			}
+			return true
+		}),
+	}}
+}
+
+// Rule adds or replaces a rule, making it usable in the `validate` tag
+func (v *TagValidator) Rule(name string, rule ValidationRule) {
+	v.rules[name] = rule
+}
+
+// Validate validates the struct, or pointer to struct, and returns ValidationErrors if any fields are invalid.
+// A tag using a rule which doesn't exist returns an error wrapping ErrUnknownRule
+func (v *TagValidator) Validate(i interface{}) error {
+	value := reflect.ValueOf(i)
+	for value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			return nil
+		}
+		value = value.Elem()
+	}
+
+	if value.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var fieldErrors ValidationErrors
+	if err := v.validateStruct(value, "", &fieldErrors); err != nil {
+		return err
+	}
+	if len(fieldErrors) > 0 {
+		return fieldErrors
+	}
+
+	return nil
+}
+
+func (v *TagValidator) validateStruct(value reflect.Value, namespace string, fieldErrors *ValidationErrors) error {
+	valueType := value.Type()
+
+	for i := 0; i < valueType.NumField(); i++ {
+		field, fieldValue := valueType.Field(i), value.Field(i)
+
+		if field.Anonymous && fieldValue.Kind() == reflect.Struct {
+			if err := v.validateStruct(fieldValue, namespace, fieldErrors); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if !field.IsExported() {
+			continue
+		}
+
+		name := namespace + fieldName(field)
+
+		if tag := field.Tag.Get("validate"); tag != "" && tag != "-" {
+			if err := v.validateField(fieldValue, name, tag, fieldErrors); err != nil {
+				return err
+			}
+		}
+
+		// Nested structs are validated with the field name as namespace
+		nested := fieldValue
+		if nested.Kind() == reflect.Ptr && !nested.IsNil() {
+			nested = nested.Elem()
+		}
+		if nested.Kind() == reflect.Struct && nested.Type() != timeType {
+			if err := v.validateStruct(nested, name+".", fieldErrors); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (v *TagValidator) validateField(field reflect.Value, name, tag string, fieldErrors *ValidationErrors) error {
+	for _, rule := range strings.Split(tag, ",") {
+		rule, param, _ := strings.Cut(strings.TrimSpace(rule), "=")
+
+		// Empty rules, e.g. from a trailing comma, are skipped
+		if rule == "" {
+			continue
+		}
+
+		check, ok := v.rules[rule]
+		if !ok {
+			return fmt.Errorf("%w '%s' for field '%s'", ErrUnknownRule, rule, name)
+		}
+
+		// Only required is checked for empty values, making all other rules optional
+		if rule != "required" && field.IsZero() {
+			continue
+		}
+
+		value := field
+		if value.Kind() == reflect.Ptr {
+			value = value.Elem()
+		}
+
+		if !check(value, param) {
+			*fieldErrors = append(*fieldErrors, FieldError{name, rule, param, validationMessage(name, rule, param)})
+		}
+	}
+
+	return nil
+}
+
+// fieldName returns the name of the field as it's known by the client, using the json tag if set
+func fieldName(field reflect.StructField) string {
+	if name, _, _ := strings.Cut(field.Tag.Get("json"), ","); name != "" && name != "-" {
+		return name
+	}
+
+	return field.Name
+}
+
+func validationMessage(name, rule, param string) string {
+	switch rule {
+	case "required":
+		return fmt.Sprintf("%s is required", name)
+	case "min":
+		return fmt.Sprintf("%s must be at least %s", name, param)
+	case "max":
+		return fmt.Sprintf("%s must be at most %s", name, param)
+	case "len":
+		return fmt.Sprintf("%s must have a length of %s", name, param)
+	case "email":
+		return fmt.Sprintf("%s must be a valid email address", name)
+	case "oneof":
+		return fmt.Sprintf("%s must be one of [%s]", name, param)
+	}
+
+	return fmt.Sprintf("%s does not satisfy '%s'", name, rule)
+}
+
+// compareRule creates a rule comparing the parameter against the length of strings, slices and maps,
+// or against the value of numbers
+func compareRule(compare func(value, param float64) bool) ValidationRule {
+	return func(field reflect.Value, param string) bool {
+		limit, err := strconv.ParseFloat(param, 64)
+		if err != nil {
+			return false
+		}
+
+		switch field.Kind() {
+		case reflect.String:
+			return compare(float64(len([]rune(field.String()))), limit)
+		case reflect.Slice, reflect.Map, reflect.Array:
+			return compare(float64(field.Len()), limit)
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return compare(float64(field.Int()), limit)
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return compare(float64(field.Uint()), limit)
+		case reflect.Float32, reflect.Float64:
+			return compare(field.Float(), limit)
+		}
+
+		return false
+	}
+}
+
+// stringRule creates a rule only valid for strings
+func stringRule(check func(value, param string) bool) ValidationRule {
+	return func(field reflect.Value, param string) bool {
+		return field.Kind() == reflect.String && check(field.String(), param)
+	}
+}
+
+func isEmail(value string) bool {
+	address, err := mail.ParseAddress(value)
+	return err == nil && address.Address == value
+}
+
+// Validate validates the value using the Validator of the router.
+// Errors other than ValidationErrors and ErrUnknownRule are wrapped in a ValidationError
+func (c *Context) Validate(v interface{}) error {
+	validator := Validator(defaultValidator)
+	if c.router != nil && c.router.Validator != nil {
+		validator = c.router.Validator
+	}
+
+	err := validator.Validate(v)
+
+	var fieldErrors ValidationErrors
+	var validationError *ValidationError
+	if err == nil || errors.As(err, &fieldErrors) || errors.As(err, &validationError) || errors.Is(err, ErrUnknownRule) {
+		return err
+	}
+
+	return &ValidationError{err}
+}
+
+// MustBind binds the request to dst and validates it.
+// If either fails, the error is passed to the error handler of the router and false is returned.
+// The default error handler answers with http.StatusBadRequest for binding errors,
+// and with http.StatusUnprocessableEntity including the field errors for validation errors
+func (c *Context) MustBind(dst interface{}) bool {
+	err := c.Bind(dst)
+	if err == nil {
+		err = c.Validate(dst)
+	}
+
+	if err != nil {
+		c.Error(err)
+		return false
+	}
+
+	return true
+}
+
+// Used when the Context has no router, e.g. when created outside of a request
+var defaultValidator = NewTagValidator()
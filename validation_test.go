@@ -0,0 +1,169 @@
+package fit
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type validationAddress struct {
+	City string `json:"city" validate:"required"`
+}
+
+type validationTarget struct {
+	Name    string             `json:"name" validate:"required,min=3"`
+	Email   string             `json:"email" validate:"email"`
+	Age     int                `json:"age" validate:"min=18,max=130"`
+	Role    string             `json:"role" validate:"oneof=admin user"`
+	Address *validationAddress `json:"address"`
+}
+
+func TestTagValidator(t *testing.T) {
+	validator := NewTagValidator()
+
+	valid := validationTarget{"john", "john@example.com", 40, "admin", &validationAddress{"Copenhagen"}}
+	if err := validator.Validate(&valid); err != nil {
+		t.Errorf("Expected the target to be valid, got '%s'", err)
+	}
+
+	// Email and role are optional, as they're not required
+	if err := validator.Validate(validationTarget{Name: "john", Age: 18}); err != nil {
+		t.Errorf("Expected empty optional fields to be valid, got '%s'", err)
+	}
+
+	invalid := validationTarget{"jo", "john@", 12, "guest", &validationAddress{}}
+	err := validator.Validate(invalid)
+
+	fieldErrors, ok := err.(ValidationErrors)
+	if !ok {
+		t.Fatalf("Expected ValidationErrors, got %v", err)
+	}
+
+	var fields []string
+	for _, fieldError := range fieldErrors {
+		fields = append(fields, fieldError.Field+":"+fieldError.Rule)
+	}
+
+	expected := []string{"name:min", "email:email", "age:min", "role:oneof", "address.city:required"}
+	if !reflect.DeepEqual(fields, expected) {
+		t.Errorf("Field errors are wrong. Expected %s, got %s", expected, fields)
+	}
+}
+
+func TestTagValidatorCustomRule(t *testing.T) {
+	validator := NewTagValidator()
+	validator.Rule("even", func(field reflect.Value, _ string) bool {
+		return field.Int()%2 == 0
+	})
+
+	target := struct {
+		Number int `validate:"even"`
+	}{3}
+
+	if err := validator.Validate(target); err == nil {
+		t.Error("Expected the custom rule to fail")
+	}
+}
+
+func TestTagValidatorMalformedTags(t *testing.T) {
+	validator := NewTagValidator()
+
+	trailing := struct {
+		Name string `validate:"required,"`
+	}{"john"}
+
+	if err := validator.Validate(trailing); err != nil {
+		t.Errorf("Empty rules should be skipped, got %v", err)
+	}
+
+	typo := struct {
+		Name string `validate:"requird"`
+	}{"john"}
+
+	r := NewRouter()
+	r.Get("/typo", func(c *Context) {
+		if err := c.Validate(typo); err != nil {
+			c.Error(err)
+		}
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/typo", nil))
+
+	if err := validator.Validate(typo); !errors.Is(err, ErrUnknownRule) || w.Code != http.StatusInternalServerError {
+		t.Errorf("Expected ErrUnknownRule rendered as %d, got %v with %d", http.StatusInternalServerError, err, w.Code)
+	}
+}
+
+func TestContextMustBind(t *testing.T) {
+	r := NewRouter()
+	r.Post("/users", func(c *Context) {
+		var target validationTarget
+		if !c.MustBind(&target) {
+			return
+		}
+		c.JSON(target.Name, http.StatusCreated)
+	})
+
+	tests := []struct {
+		body   string
+		status int
+	}{
+		{`{"name": "john", "age": 40}`, http.StatusCreated},
+		{`{"name": "jo", "age": 40}`, http.StatusUnprocessableEntity},
+		{`{"name": `, http.StatusBadRequest},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest("POST", "/users", strings.NewReader(test.body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != test.status {
+			t.Errorf("Status for '%s' is wrong. Expected %d, got %d", test.body, test.status, w.Code)
+		}
+
+		if test.status == http.StatusUnprocessableEntity {
+			var response struct {
+				Errors []FieldError `json:"errors"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil || len(response.Errors) != 1 || response.Errors[0].Field != "name" {
+				t.Errorf("Field errors were not rendered, got '%s'", w.Body.String())
+			}
+		}
+	}
+}
+
+type stubValidator struct{}
+
+func (stubValidator) Validate(v interface{}) error {
+	return errors.New("name is taken")
+}
+
+func TestContextMustBindCustomValidator(t *testing.T) {
+	r := NewRouter()
+	r.Validator = stubValidator{}
+	r.Post("/users", func(c *Context) {
+		var target validationTarget
+		if c.MustBind(&target) {
+			c.JSON(target.Name, http.StatusCreated)
+		}
+	})
+
+	req := httptest.NewRequest("POST", "/users", strings.NewReader(`{"name": "john"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	var response map[string]string
+	json.Unmarshal(w.Body.Bytes(), &response)
+
+	if w.Code != http.StatusUnprocessableEntity || response["message"] != "name is taken" {
+		t.Errorf("Errors of a custom validator should be rendered as %d, got %d '%s'", http.StatusUnprocessableEntity, w.Code, w.Body.String())
+	}
+}